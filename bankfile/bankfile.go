@@ -0,0 +1,51 @@
+// Package bankfile reads transactions from the CSV and OFX/QFX files
+// banks export.
+package bankfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haclark30/vitus/ynab"
+)
+
+// Transaction is a transaction read from a bank file. Amount is negative
+// for outflows. Id is the bank's own id for the transaction, when the
+// file has one.
+type Transaction struct {
+	Date   time.Time
+	Amount ynab.Milliunits
+	Payee  string
+	Memo   string
+	Id     string
+}
+
+// parseAmount parses amounts as banks write them, with currency symbols,
+// thousands separators and negatives either signed or in parentheses.
+func parseAmount(s string) (ynab.Milliunits, error) {
+	clean := strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(clean, "(") && strings.HasSuffix(clean, ")") {
+		neg = true
+		clean = clean[1 : len(clean)-1]
+	}
+	clean = strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '+' {
+			return r
+		}
+		return -1
+	}, clean)
+	if clean == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid amount: %q", s)
+	}
+	if neg {
+		f = -f
+	}
+	return ynab.FromFloat64(f), nil
+}
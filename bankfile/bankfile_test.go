@@ -0,0 +1,43 @@
+package bankfile
+
+import (
+	"testing"
+
+	"github.com/haclark30/vitus/ynab"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		s    string
+		want ynab.Milliunits
+	}{
+		{"12.34", 12340},
+		{"-12.34", -12340},
+		{"+12.34", 12340},
+		{" 7 ", 7000},
+		{"(12.34)", -12340},
+		{"($1,234.56)", -1234560},
+		{"$1,234.56", 1234560},
+		{"-$1,234.56", -1234560},
+		{"£0.99", 990},
+		{"1,000,000", 1000000000},
+		{"12.345 EUR", 12345},
+		{"", 0},
+		{"  ", 0},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.s)
+		if err != nil {
+			t.Errorf("parseAmount(%q) error: %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAmount(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+	for _, s := range []string{"1.2.3", "--5", "1-2"} {
+		if got, err := parseAmount(s); err == nil {
+			t.Errorf("parseAmount(%q) = %d, want an error", s, got)
+		}
+	}
+}
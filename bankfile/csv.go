@@ -0,0 +1,157 @@
+package bankfile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVMapping says which columns of a CSV file hold which fields. Columns
+// are given by header name, or by 1-based number for files without a
+// header. Amounts come from separate Inflow and Outflow columns when
+// either is given, otherwise from Amount.
+type CSVMapping struct {
+	Date    string
+	Payee   string
+	Memo    string
+	Amount  string
+	Inflow  string
+	Outflow string
+
+	// DateFormats are tried in order to parse the date column.
+	DateFormats []string
+	// Negate flips the sign of amounts, for files where purchases are positive.
+	Negate    bool
+	NoHeader  bool
+	Delimiter rune
+}
+
+// DefaultDateFormats are the date formats tried when a mapping has none.
+var DefaultDateFormats = []string{"2006-01-02", "01/02/2006", "1/2/2006", "2006/01/02", "01/02/06", "1/2/06"}
+
+// ReadCSV reads the transactions of a CSV file using mapping.
+func ReadCSV(r io.Reader, mapping CSVMapping) ([]Transaction, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if mapping.Delimiter != 0 {
+		reader.Comma = mapping.Delimiter
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	var header []string
+	if !mapping.NoHeader {
+		header, records = records[0], records[1:]
+	}
+	column := func(name, spec string, required bool) (int, error) {
+		if spec == "" {
+			if required {
+				return -1, fmt.Errorf("no %s column given", name)
+			}
+			return -1, nil
+		}
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), spec) {
+				return i, nil
+			}
+		}
+		if n, err := strconv.Atoi(spec); err == nil && n > 0 {
+			return n - 1, nil
+		}
+		return -1, fmt.Errorf("no %s column %q in %v", name, spec, header)
+	}
+
+	dateCol, err := column("date", mapping.Date, true)
+	if err != nil {
+		return nil, err
+	}
+	payeeCol, err := column("payee", mapping.Payee, true)
+	if err != nil {
+		return nil, err
+	}
+	memoCol, err := column("memo", mapping.Memo, false)
+	if err != nil {
+		return nil, err
+	}
+	amountCol := -1
+	if mapping.Inflow == "" && mapping.Outflow == "" {
+		if amountCol, err = column("amount", mapping.Amount, true); err != nil {
+			return nil, err
+		}
+	}
+	inflowCol, err := column("inflow", mapping.Inflow, false)
+	if err != nil {
+		return nil, err
+	}
+	outflowCol, err := column("outflow", mapping.Outflow, false)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := mapping.DateFormats
+	if len(formats) == 0 {
+		formats = DefaultDateFormats
+	}
+
+	var transactions []Transaction
+	for n, record := range records {
+		field := func(col int) string {
+			if col < 0 || col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+		// skip blank lines and trailing summary rows without a date
+		if field(dateCol) == "" {
+			continue
+		}
+		line := n + 1
+		if !mapping.NoHeader {
+			line++
+		}
+
+		date, err := parseDate(field(dateCol), formats)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		t := Transaction{Date: date, Payee: field(payeeCol), Memo: field(memoCol)}
+		if amountCol >= 0 {
+			if t.Amount, err = parseAmount(field(amountCol)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		} else {
+			inflow, err := parseAmount(field(inflowCol))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			outflow, err := parseAmount(field(outflowCol))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			t.Amount = inflow.Abs() - outflow.Abs()
+		}
+		if mapping.Negate {
+			t.Amount = -t.Amount
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+func parseDate(s string, formats []string) (time.Time, error) {
+	for _, f := range formats {
+		if t, err := time.ParseInLocation(f, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q doesn't match any of %v", s, formats)
+}
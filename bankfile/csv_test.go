@@ -0,0 +1,100 @@
+package bankfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// summary writes transactions one per line as date|payee|memo|amount|id.
+func summary(transactions []Transaction) string {
+	var lines []string
+	for _, t := range transactions {
+		lines = append(lines, fmt.Sprintf("%s|%s|%s|%d|%s",
+			t.Date.Format("2006-01-02"), t.Payee, t.Memo, t.Amount, t.Id))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		mapping CSVMapping
+		want    string
+	}{
+		{
+			name: "amount column",
+			file: "Date,Description,Amount\n" +
+				"2024-03-01,Coffee,-4.50\n" +
+				"2024-03-02,Paycheck,\"$1,500.00\"\n",
+			mapping: CSVMapping{Date: "date", Payee: "Description", Amount: "Amount"},
+			want: "2024-03-01|Coffee||-4500|\n" +
+				"2024-03-02|Paycheck||1500000|",
+		},
+		{
+			name: "parenthesised negatives and a memo",
+			file: "Posted,Payee,Notes,Amount\n" +
+				"03/05/2024,Rent,March,\"($1,200.00)\"\n",
+			mapping: CSVMapping{Date: "Posted", Payee: "Payee", Memo: "Notes", Amount: "Amount"},
+			want:    "2024-03-05|Rent|March|-1200000|",
+		},
+		{
+			name: "split inflow and outflow",
+			file: "Date,Payee,Inflow,Outflow\n" +
+				"2024-03-01,Groceries,,52.10\n" +
+				"2024-03-02,Refund,10.00,\n" +
+				"2024-03-03,Both,1.00,-3.00\n",
+			mapping: CSVMapping{Date: "Date", Payee: "Payee", Inflow: "Inflow", Outflow: "Outflow"},
+			want: "2024-03-01|Groceries||-52100|\n" +
+				"2024-03-02|Refund||10000|\n" +
+				"2024-03-03|Both||-2000|",
+		},
+		{
+			name: "numbered columns without a header",
+			file: "15/03/2024;12.50;Shop\n" +
+				";;\n" +
+				"16/03/2024;-3;Refund\n",
+			mapping: CSVMapping{Date: "1", Payee: "3", Amount: "2", NoHeader: true,
+				Delimiter: ';', DateFormats: []string{"02/01/2006"}, Negate: true},
+			want: "2024-03-15|Shop||-12500|\n" +
+				"2024-03-16|Refund||3000|",
+		},
+		{
+			name: "trailing summary row",
+			file: "Date,Description,Amount\n" +
+				"2024-03-01,Coffee,-4.50\n" +
+				",Total,-4.50\n",
+			mapping: CSVMapping{Date: "Date", Payee: "Description", Amount: "Amount"},
+			want:    "2024-03-01|Coffee||-4500|",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadCSV(strings.NewReader(tt.file), tt.mapping)
+		if err != nil {
+			t.Errorf("%s: ReadCSV error: %v", tt.name, err)
+			continue
+		}
+		if summary(got) != tt.want {
+			t.Errorf("%s: ReadCSV =\n%s\nwant\n%s", tt.name, summary(got), tt.want)
+		}
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		mapping CSVMapping
+	}{
+		{"no payee column", "Date,Amount\n2024-03-01,1\n", CSVMapping{Date: "Date", Amount: "Amount"}},
+		{"unknown column", "Date,Payee,Amount\n2024-03-01,x,1\n", CSVMapping{Date: "Date", Payee: "Name", Amount: "Amount"}},
+		{"bad date", "Date,Payee,Amount\nMarch 1,x,1\n", CSVMapping{Date: "Date", Payee: "Payee", Amount: "Amount"}},
+		{"bad amount", "Date,Payee,Amount\n2024-03-01,x,1.2.3\n", CSVMapping{Date: "Date", Payee: "Payee", Amount: "Amount"}},
+	}
+	for _, tt := range tests {
+		if got, err := ReadCSV(strings.NewReader(tt.file), tt.mapping); err == nil {
+			t.Errorf("%s: ReadCSV = %v, want an error", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package bankfile
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	stmtTrnRe = regexp.MustCompile(`(?is)<STMTTRN>(.*?)(?:</STMTTRN>|<STMTTRN>|</BANKTRANLIST>)`)
+	ofxTagRe  = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+)
+
+// ReadOFX reads the transactions of an OFX or QFX file. Both the SGML
+// form of OFX 1.x, where elements aren't closed, and the XML of OFX 2.x
+// are read.
+func ReadOFX(r io.Reader) ([]Transaction, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	body := string(b)
+
+	var transactions []Transaction
+	for {
+		loc := stmtTrnRe.FindStringSubmatchIndex(body)
+		if loc == nil {
+			break
+		}
+		block := body[loc[2]:loc[3]]
+		// start the next search at the end of this block so an unclosed
+		// STMTTRN followed by another is read as two transactions
+		body = body[loc[3]:]
+
+		fields := make(map[string]string)
+		for _, m := range ofxTagRe.FindAllStringSubmatch(block, -1) {
+			fields[strings.ToUpper(m[1])] = strings.TrimSpace(m[2])
+		}
+		t, err := ofxTransaction(fields)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if len(transactions) == 0 && !strings.Contains(strings.ToUpper(string(b)), "<OFX>") {
+		return nil, fmt.Errorf("not an OFX file")
+	}
+	return transactions, nil
+}
+
+func ofxTransaction(fields map[string]string) (Transaction, error) {
+	date, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		return Transaction{}, err
+	}
+	amount, err := parseAmount(fields["TRNAMT"])
+	if err != nil {
+		return Transaction{}, err
+	}
+	payee := fields["NAME"]
+	if payee == "" {
+		payee = fields["PAYEE"]
+	}
+	return Transaction{
+		Date:   date,
+		Amount: amount,
+		Payee:  unescapeOFX(payee),
+		Memo:   unescapeOFX(fields["MEMO"]),
+		Id:     fields["FITID"],
+	}, nil
+}
+
+// parseOFXDate parses the date part of an OFX datetime,
+// YYYYMMDD[HHMMSS[.XXX]][[gmt offset:tz name]].
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("not a valid OFX date: %q", s)
+	}
+	t, err := time.ParseInLocation("20060102", s[:8], time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not a valid OFX date: %q", s)
+	}
+	return t, nil
+}
+
+var ofxEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+
+func unescapeOFX(s string) string {
+	return ofxEntities.Replace(s)
+}
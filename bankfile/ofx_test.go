@@ -0,0 +1,95 @@
+package bankfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadOFX(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "SGML without closing tags",
+			file: `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<DTSTART>20240301
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240301120000.000[-5:EST]
+<TRNAMT>-4.50
+<FITID>202403010001
+<NAME>COFFEE &amp; CO
+<MEMO>card 1234
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240302
+<TRNAMT>1500.00
+<FITID>202403020001
+<PAYEE>EMPLOYER
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`,
+			want: "2024-03-01|COFFEE & CO|card 1234|-4500|202403010001\n" +
+				"2024-03-02|EMPLOYER||1500000|202403020001",
+		},
+		{
+			name: "SGML on one line",
+			file: "<OFX><BANKTRANLIST><STMTTRN><DTPOSTED>20240305<TRNAMT>-1,200.00<FITID>A1<NAME>RENT" +
+				"<STMTTRN><DTPOSTED>20240306<TRNAMT>3<FITID>A2<NAME>INTEREST</BANKTRANLIST></OFX>",
+			want: "2024-03-05|RENT||-1200000|A1\n" +
+				"2024-03-06|INTEREST||3000|A2",
+		},
+		{
+			name: "XML",
+			file: `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<stmttrn>
+  <TRNTYPE>DEBIT</TRNTYPE>
+  <DTPOSTED>20240310</DTPOSTED>
+  <TRNAMT>-52.10</TRNAMT>
+  <FITID>X-9</FITID>
+  <NAME>GROCER</NAME>
+</stmttrn>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`,
+			want: "2024-03-10|GROCER||-52100|X-9",
+		},
+		{
+			name: "no transactions",
+			file: "<OFX><BANKTRANLIST></BANKTRANLIST></OFX>",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadOFX(strings.NewReader(tt.file))
+		if err != nil {
+			t.Errorf("%s: ReadOFX error: %v", tt.name, err)
+			continue
+		}
+		if summary(got) != tt.want {
+			t.Errorf("%s: ReadOFX =\n%s\nwant\n%s", tt.name, summary(got), tt.want)
+		}
+	}
+}
+
+func TestReadOFXErrors(t *testing.T) {
+	for name, file := range map[string]string{
+		"not OFX":    "Date,Payee,Amount\n2024-03-01,x,1\n",
+		"bad date":   "<OFX><STMTTRN><DTPOSTED>2024<TRNAMT>1</STMTTRN></OFX>",
+		"bad amount": "<OFX><STMTTRN><DTPOSTED>20240301<TRNAMT>1.2.3</STMTTRN></OFX>",
+	} {
+		if got, err := ReadOFX(strings.NewReader(file)); err == nil {
+			t.Errorf("%s: ReadOFX = %v, want an error", name, got)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tslc "github.com/NimbleMarkets/ntcharts/linechart/timeserieslinechart"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/fitbit"
+)
+
+// activityRecord is an activity logged on fitbit, e.g. a walk or a run.
+type activityRecord struct {
+	LogId    int64
+	Start    time.Time
+	Name     string
+	Duration time.Duration
+	Calories int
+	Steps    int
+}
+
+func (a activityRecord) End() time.Time {
+	return a.Start.Add(a.Duration)
+}
+
+// activityTypeStats totals the activities of one name.
+type activityTypeStats struct {
+	Name     string
+	Count    int
+	Duration time.Duration
+	Calories int
+	Steps    int
+}
+
+// summarizeActivities totals activities by name, longest total first.
+func summarizeActivities(activities []activityRecord) []activityTypeStats {
+	byName := make(map[string]*activityTypeStats)
+	var stats []*activityTypeStats
+	for _, a := range activities {
+		s, ok := byName[a.Name]
+		if !ok {
+			s = &activityTypeStats{Name: a.Name}
+			byName[a.Name] = s
+			stats = append(stats, s)
+		}
+		s.Count++
+		s.Duration += a.Duration
+		s.Calories += a.Calories
+		s.Steps += a.Steps
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Duration > stats[j].Duration })
+
+	summary := make([]activityTypeStats, len(stats))
+	for i, s := range stats {
+		summary[i] = *s
+	}
+	return summary
+}
+
+// activityDetail is the heart rate recorded during an activity.
+type activityDetail struct {
+	logId       int64
+	heart       []tslc.TimePoint
+	zones       []fitbit.HeartRateZone
+	zoneMinutes map[string]int
+}
+
+// ActivityView lists the activities of a month and shows the heart rate
+// of the selected one.
+type ActivityView struct {
+	db         *sql.DB
+	month      time.Time
+	activities []activityRecord // newest first
+	stats      []activityTypeStats
+	cursor     int
+	showDetail bool
+	detail     activityDetail
+	chart      tslc.Model
+	spinner    loadingSpinner
+	focused    bool
+	width      int
+	height     int
+	compact    bool
+}
+
+// activityDataMsg carries the activities of month.
+type activityDataMsg struct {
+	month      time.Time
+	activities []activityRecord
+}
+
+// activityDetailMsg carries the heart rate of an activity.
+type activityDetailMsg activityDetail
+
+// NewActivityView returns an empty view of this month, its data is
+// loaded by Init.
+func NewActivityView(db *sql.DB, width, height int) ActivityView {
+	chart := tslc.New(width, height,
+		tslc.WithXLabelFormatter(LocalHourLabelFormatter()),
+		tslc.WithYRange(50, 175),
+	)
+	chart.AutoMinX = false
+	chart.AutoMaxX = false
+	chart.SetStyle(heartStyle)
+	now := time.Now()
+	return ActivityView{
+		db:      db,
+		month:   time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+		chart:   chart,
+		spinner: newLoadingSpinner(),
+	}
+}
+
+// Init starts loading the month's activities.
+func (a ActivityView) Init() tea.Cmd {
+	return tea.Batch(a.spinner.Tick, a.load())
+}
+
+func (a ActivityView) load() tea.Cmd {
+	db, month := a.db, a.month
+	return func() tea.Msg {
+		return activityDataMsg{month: month, activities: GetActivities(db, month, month.AddDate(0, 1, 0))}
+	}
+}
+
+func (a ActivityView) loadDetail() tea.Cmd {
+	if a.cursor >= len(a.activities) {
+		return nil
+	}
+	db, act := a.db, a.activities[a.cursor]
+	return func() tea.Msg {
+		heart := GetHeartBetween(db, act.Start, act.End())
+		zones := GetHeartZones(db, act.Start)
+		return activityDetailMsg{
+			logId:       act.LogId,
+			heart:       heart,
+			zones:       zones,
+			zoneMinutes: zoneMinutes(zones, heart),
+		}
+	}
+}
+
+// SetDate shows the month containing date's day.
+func (a *ActivityView) SetDate(date viewDate) tea.Cmd {
+	month := time.Date(date.day.Year(), date.day.Month(), 1, 0, 0, 0, 0, time.Local)
+	if month.Equal(a.month) {
+		return nil
+	}
+	a.month = month
+	a.cursor = 0
+	a.showDetail = false
+	return tea.Batch(a.spinner.start(), a.load())
+}
+
+const activitySideWidth = 18
+
+// SetSize fits the list, or the chart and the zones beside it, into
+// width by height.
+func (a *ActivityView) SetSize(width, height int) {
+	a.width, a.height = width, height
+	a.compact = width < compactPanelWidth
+	// the title above the chart, and the zones below it when compact
+	if a.compact {
+		a.chart.Resize(max(width, 1), max(height-3, 1))
+	} else {
+		a.chart.Resize(max(width-activitySideWidth, 1), max(height-2, 1))
+	}
+	a.draw()
+}
+
+func (a *ActivityView) Focus() {
+	a.focused = true
+}
+
+func (a *ActivityView) Blur() {
+	a.focused = false
+}
+
+func (a ActivityView) Focused() bool {
+	return a.focused
+}
+
+func (a ActivityView) Update(msg tea.Msg) (ActivityView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case activityDataMsg:
+		if !msg.month.Equal(a.month) {
+			return a, nil
+		}
+		a.spinner.stop()
+		// keep the selected activity when the month is reloaded
+		selected := a.selected()
+		a.activities = msg.activities
+		a.stats = summarizeActivities(a.activities)
+		a.cursor = 0
+		for i, act := range a.activities {
+			if act.LogId == selected.LogId {
+				a.cursor = i
+			}
+		}
+		if a.showDetail {
+			return a, a.loadDetail()
+		}
+	case activityDetailMsg:
+		if msg.logId != a.selected().LogId {
+			return a, nil
+		}
+		a.detail = activityDetail(msg)
+		a.draw()
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		a.spinner, cmd = a.spinner.update(msg)
+		return a, cmd
+	case tea.KeyMsg:
+		if len(a.activities) == 0 {
+			return a, nil
+		}
+		switch {
+		case key.Matches(msg, teaKeys.Up):
+			a.cursor = max(a.cursor-1, 0)
+		case key.Matches(msg, teaKeys.Down):
+			a.cursor = min(a.cursor+1, len(a.activities)-1)
+		case key.Matches(msg, teaKeys.Select):
+			a.showDetail = !a.showDetail
+		default:
+			return a, nil
+		}
+		if a.showDetail {
+			return a, a.loadDetail()
+		}
+	}
+	return a, nil
+}
+
+// selected returns the activity under the cursor.
+func (a ActivityView) selected() activityRecord {
+	if a.cursor >= len(a.activities) {
+		return activityRecord{}
+	}
+	return a.activities[a.cursor]
+}
+
+// draw plots the heart rate of the selected activity over its duration.
+func (a *ActivityView) draw() {
+	act := a.selected()
+	if act.LogId == 0 || a.detail.logId != act.LogId {
+		return
+	}
+	a.chart.ClearAllData()
+	a.chart.Clear()
+	a.chart.SetTimeRange(act.Start, act.End())
+	a.chart.SetViewTimeRange(act.Start, act.End())
+	for _, p := range a.detail.heart {
+		a.chart.Push(p)
+	}
+	if len(a.detail.heart) == 0 {
+		a.chart.DrawXYAxisAndLabel()
+		return
+	}
+	a.chart.DrawBraille()
+}
+
+func (a ActivityView) View() string {
+	if len(a.activities) == 0 {
+		if a.spinner.loading {
+			return a.spinner.title("loading activities")
+		}
+		return "no activities in " + a.month.Format("January 2006") + ", run vitus sync"
+	}
+	if a.showDetail {
+		return a.detailView()
+	}
+
+	var b strings.Builder
+	b.WriteString(a.spinner.title("activities, "+a.month.Format("January 2006")) + "\n\n")
+
+	// the month's totals below the list take a line a type, up to a third
+	// of the panel
+	stats := a.stats[:min(len(a.stats), max(a.height/3, 1))]
+	lines := max(a.height-6-len(stats), 1)
+	b.WriteString(activityHeaderStyle.Render("  "+a.row("when", "activity", "time", "cal", "steps")) + "\n")
+	start := max(0, min(a.cursor-lines/2, len(a.activities)-lines))
+	end := min(len(a.activities), start+lines)
+	for i := start; i < end; i++ {
+		act := a.activities[i]
+		line := a.row(act.Start.Format("Mon 02 15:04"), act.Name, formatActivityDuration(act.Duration),
+			fmt.Sprint(act.Calories), fmt.Sprint(act.Steps))
+		if i == a.cursor && a.focused {
+			line = cursorStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n" + activityHeaderStyle.Render("  "+a.row("month", "activity", "time", "cal", "steps")) + "\n")
+	for _, s := range stats {
+		b.WriteString("  " + a.row(fmt.Sprintf("%d×", s.Count), s.Name, formatActivityDuration(s.Duration),
+			fmt.Sprint(s.Calories), fmt.Sprint(s.Steps)) + "\n")
+	}
+	return lipgloss.NewStyle().Width(a.width).Align(lipgloss.Left).Render(b.String())
+}
+
+// row lays out a line of the list, leaving out the steps when compact.
+func (a ActivityView) row(when, name, duration, calories, steps string) string {
+	nameWidth := max(a.width-2-14-8-7-8-4, 8)
+	cells := []string{
+		lipgloss.NewStyle().Width(14).Render(when),
+		lipgloss.NewStyle().Width(nameWidth).MaxWidth(nameWidth).Render(name),
+		lipgloss.NewStyle().Width(8).Align(lipgloss.Right).Render(duration),
+		lipgloss.NewStyle().Width(7).Align(lipgloss.Right).Render(calories),
+	}
+	if !a.compact {
+		cells = append(cells, lipgloss.NewStyle().Width(8).Align(lipgloss.Right).Render(steps))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, cells...)
+}
+
+// detailView shows the heart rate of the selected activity, its time in
+// each zone and its steps.
+func (a ActivityView) detailView() string {
+	act := a.selected()
+	title := a.spinner.title(fmt.Sprintf("%s, %s %s-%s", act.Name,
+		act.Start.Format("Mon 2006-01-02"), act.Start.Format("15:04"), act.End().Format("15:04")))
+	chart := a.chart.View()
+	switch {
+	case a.detail.logId != act.LogId:
+		chart = lipgloss.Place(a.chart.Width(), a.chart.Height(), lipgloss.Center, lipgloss.Center,
+			"loading heart rate")
+	case len(a.detail.heart) == 0:
+		chart = lipgloss.Place(a.chart.Width(), a.chart.Height(), lipgloss.Center, lipgloss.Center,
+			"no heart rate recorded")
+	}
+
+	var zones []string
+	for i := len(a.detail.zones) - 1; i >= 0; i-- {
+		z := a.detail.zones[i]
+		mins := a.detail.zoneMinutes[z.Name]
+		if a.compact && mins == 0 {
+			continue
+		}
+		zones = append(zones, zoneLabel(i, z.Name)+
+			fmt.Sprintf(" %dm", mins))
+	}
+	totals := []string{
+		formatActivityDuration(act.Duration),
+		fmt.Sprintf("%d cal", act.Calories),
+		fmt.Sprintf("%d steps", act.Steps),
+	}
+
+	if a.compact {
+		return lipgloss.JoinVertical(lipgloss.Center,
+			title,
+			chart,
+			strings.Join(totals, "  "),
+			strings.Join(zones, "  "),
+		)
+	}
+	side := strings.Join(totals, "\n") + "\n\n" + strings.Join(zones, "\n")
+	return lipgloss.JoinVertical(lipgloss.Center,
+		title,
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Center,
+			chart,
+			lipgloss.NewStyle().Width(activitySideWidth).PaddingLeft(2).Align(lipgloss.Left).Render(side),
+		),
+	)
+}
+
+// formatActivityDuration formats d as e.g. "45m" or "1h 05m".
+func formatActivityDuration(d time.Duration) string {
+	mins := int(d.Round(time.Minute).Minutes())
+	if mins < 60 {
+		return fmt.Sprintf("%dm", mins)
+	}
+	return fmt.Sprintf("%dh %02dm", mins/60, mins%60)
+}
+
+// GetActivities returns the activities logged from start up to but not
+// including end, newest first.
+func GetActivities(db *sql.DB, start, end time.Time) []activityRecord {
+	rows, err := db.Query(
+		`SELECT logId, startTime, name, duration, calories, steps
+		FROM ActivityRecords
+		WHERE date >= ? AND date < ?
+		ORDER BY startTime DESC`,
+		start.Format("2006-01-02"), end.Format("2006-01-02"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	var activities []activityRecord
+	for rows.Next() {
+		var a activityRecord
+		var startTime, durationMs int64
+		if err := rows.Scan(&a.LogId, &startTime, &a.Name, &durationMs, &a.Calories, &a.Steps); err != nil {
+			log.Fatal(err)
+		}
+		a.Start = time.Unix(startTime, 0)
+		a.Duration = time.Duration(durationMs) * time.Millisecond
+		activities = append(activities, a)
+	}
+	return activities
+}
+
+// GetHeartBetween returns the intraday heart rate from start up to but
+// not including end.
+func GetHeartBetween(db *sql.DB, start, end time.Time) []tslc.TimePoint {
+	rows, err := db.Query(
+		`SELECT time, heartRate FROM HeartRateRecords
+		WHERE time >= ? AND time < ?
+		ORDER BY time`,
+		start.Unix(), end.Unix(),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	var points []tslc.TimePoint
+	for rows.Next() {
+		var p tslc.TimePoint
+		var t int64
+		if err := rows.Scan(&t, &p.Value); err != nil {
+			log.Fatal(err)
+		}
+		p.Time = time.Unix(t, 0)
+		points = append(points, p)
+	}
+	return points
+}
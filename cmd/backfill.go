@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haclark30/vitus/fitbit"
+)
+
+// backfill loads fitbit data for a range of days with a pool of workers.
+// Each job is committed on its own along with a checkpoint for every
+// completed day, so a rerun only fetches what is missing. Today is never
+// checkpointed since its data is still changing.
+type backfill struct {
+	db       *sql.DB
+	client   *http.Client
+	types    []backfillType
+	workers  int
+	progress bool
+}
+
+type backfillJob struct {
+	typ backfillType
+	r   fitbit.DateRange
+	// since is set when the job's days are fetched as one intraday range
+	// from since to now rather than a request per day.
+	since time.Time
+}
+
+// run loads start to end, stopping at the first job that fails. Jobs
+// committed before it are kept, so a rerun picks up from there.
+func (b backfill) run(start, end time.Time) error {
+	start = truncateDay(start)
+	end = truncateDay(end)
+	jobs, err := b.pendingJobs(start, end)
+	if err != nil {
+		return err
+	}
+	slog.Debug("backfill", "start", start, "end", end, "jobs", len(jobs))
+
+	workers := max(b.workers, 1)
+	var out io.Writer = io.Discard
+	if b.progress {
+		out = os.Stderr
+	}
+	bar := newProgressBar(out, len(jobs))
+
+	jobCh := make(chan backfillJob)
+	failed := make(chan struct{})
+	var failOnce sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	var dbMu sync.Mutex // sqlite only allows one writer at a time
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				err := b.load(job, &dbMu)
+				if err != nil {
+					failOnce.Do(func() {
+						firstErr = fmt.Errorf("loading %s %s: %w", job.typ.name, job.r.Start.Format("2006-01-02"), err)
+						close(failed)
+					})
+					continue
+				}
+				bar.Increment(job.typ.name)
+			}
+		}()
+	}
+send:
+	for _, job := range jobs {
+		select {
+		case jobCh <- job:
+		case <-failed:
+			break send
+		}
+	}
+	close(jobCh)
+	wg.Wait()
+	bar.Finish()
+	return firstErr
+}
+
+// load fetches a job and commits it while holding dbMu.
+func (b backfill) load(job backfillJob, dbMu *sync.Mutex) error {
+	fetch := job.typ.fetch
+	if !job.since.IsZero() {
+		// minutes past the limit are fetched again on the next load as
+		// today is never checkpointed
+		until := time.Now()
+		if limit := job.since.Add(fitbit.MaxIntradayRange); until.After(limit) {
+			until = limit
+		}
+		fetch = intradayRanges[job.typ.name].fetchSince(job.since, until)
+	}
+	write, err := fetch(b.client, job.r)
+	if err != nil {
+		return err
+	}
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	return b.commit(job, write)
+}
+
+// pendingJobs splits start to end into jobs for each type, newest first,
+// skipping any job whose days have all been checkpointed.
+func (b backfill) pendingJobs(start, end time.Time) ([]backfillJob, error) {
+	var jobs []backfillJob
+	for _, t := range b.types {
+		done, err := b.checkpoints(t.name, start, end)
+		if err != nil {
+			return nil, err
+		}
+		var typeJobs []backfillJob
+		ranges := fitbit.SplitRange(start, end, t.days)
+		slices.Reverse(ranges)
+		for _, r := range ranges {
+			complete := true
+			for d := r.Start; !d.After(r.End); d = d.AddDate(0, 0, 1) {
+				if !done[d.Format("2006-01-02")] {
+					complete = false
+					break
+				}
+			}
+			if !complete {
+				typeJobs = append(typeJobs, backfillJob{typ: t, r: r})
+			}
+		}
+		if ir, ok := intradayRanges[t.name]; ok && len(typeJobs) > 1 {
+			if typeJobs, err = b.mergeRecent(ir, typeJobs, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+		jobs = append(jobs, typeJobs...)
+	}
+	return jobs, nil
+}
+
+// mergeRecent replaces the per-day jobs of an intraday type with a single
+// intraday range job when they run up to today and every day missing is
+// within 24 hours of the latest reading stored, as after a sync the day
+// before. Otherwise jobs, newest first, are returned as they are.
+func (b backfill) mergeRecent(ir intradayRange, jobs []backfillJob, now time.Time) ([]backfillJob, error) {
+	since, err := b.latestReading(ir)
+	if err != nil || since.IsZero() || now.Sub(since) > fitbit.MaxIntradayRange {
+		return jobs, err
+	}
+	newest, oldest := jobs[0], jobs[len(jobs)-1]
+	if !newest.r.End.Equal(truncateDay(now)) || oldest.r.Start.Before(truncateDay(since)) {
+		return jobs, nil
+	}
+	slog.Debug("merging into an intraday range", "type", newest.typ.name, "since", since)
+	return []backfillJob{{
+		typ:   newest.typ,
+		r:     fitbit.DateRange{Start: oldest.r.Start, End: newest.r.End},
+		since: since,
+	}}, nil
+}
+
+// latestReading returns the time of the latest non-zero reading of an
+// intraday type, or zero if there is none. Steps are stored as zero for
+// minutes the tracker hasn't synced yet, so those don't count.
+func (b backfill) latestReading(ir intradayRange) (time.Time, error) {
+	var latest sql.NullInt64
+	err := b.db.QueryRow(
+		"SELECT max(time) FROM " + ir.table + " WHERE " + ir.column + " > 0").Scan(&latest)
+	if err != nil || !latest.Valid {
+		return time.Time{}, err
+	}
+	return time.Unix(latest.Int64, 0), nil
+}
+
+// checkpoints returns the days already loaded for a type.
+func (b backfill) checkpoints(name string, start, end time.Time) (map[string]bool, error) {
+	rows, err := b.db.Query(
+		`SELECT date(date) FROM BackfillCheckpoints WHERE type = ? AND date >= ? AND date <= ?`,
+		name, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[string]bool)
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		done[date] = true
+	}
+	return done, rows.Err()
+}
+
+// commit writes a fetched job and its checkpoints in one transaction.
+func (b backfill) commit(job backfillJob, write func(txn *sql.Tx) error) error {
+	txn, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+	if err := write(txn); err != nil {
+		return err
+	}
+
+	today := truncateDay(time.Now())
+	for d := job.r.Start; !d.After(job.r.End) && d.Before(today); d = d.AddDate(0, 0, 1) {
+		_, err = txn.Exec(
+			"INSERT OR IGNORE INTO BackfillCheckpoints (type, date) VALUES (?, ?)",
+			job.typ.name, d.Format("2006-01-02"))
+		if err != nil {
+			return err
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	slog.Debug("committed", "type", job.typ.name, "start", job.r.Start, "end", job.r.End)
+	return nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// progressBar prints a single updating line of progress with an ETA.
+type progressBar struct {
+	mu    sync.Mutex
+	out   io.Writer
+	total int
+	done  int
+	start time.Time
+}
+
+func newProgressBar(out io.Writer, total int) *progressBar {
+	return &progressBar{out: out, total: total, start: time.Now()}
+}
+
+func (p *progressBar) Increment(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+
+	const width = 30
+	filled := width * p.done / max(p.total, 1)
+	elapsed := time.Since(p.start)
+	eta := time.Duration(float64(elapsed) / float64(p.done) * float64(p.total-p.done))
+	fmt.Fprintf(p.out, "\r[%s%s] %d/%d eta %s %-14s",
+		strings.Repeat("#", filled), strings.Repeat("-", width-filled),
+		p.done, p.total, eta.Round(time.Second), label)
+}
+
+func (p *progressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.total > 0 {
+		fmt.Fprintln(p.out)
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/haclark30/vitus/fitbit"
+)
+
+func TestMergeRecent(t *testing.T) {
+	db := testDb(t)
+	at := func(d, h, m int) time.Time { return time.Date(2024, 3, d, h, m, 0, 0, time.Local) }
+	// minutes the tracker hasn't synced yet are stored as zero steps
+	_, err := db.Exec("INSERT INTO StepsRecords (time, steps) VALUES (?, 12), (?, 0)",
+		at(14, 22, 30).Unix(), at(14, 23, 59).Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := selectBackfillTypes([]string{"steps"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	days := func(from, to int) []backfillJob {
+		var jobs []backfillJob
+		for d := to; d >= from; d-- {
+			jobs = append(jobs, backfillJob{typ: steps[0], r: fitbit.DateRange{Start: at(d, 0, 0), End: at(d, 0, 0)}})
+		}
+		return jobs
+	}
+	tests := []struct {
+		name   string
+		jobs   []backfillJob
+		now    time.Time
+		merged bool
+	}{
+		{"the day before and today", days(14, 15), at(15, 8, 0), true},
+		{"over 24 hours since the last reading", days(14, 15), at(15, 22, 31), false},
+		{"a day before the last reading", days(13, 15), at(15, 8, 0), false},
+		{"not up to today", days(14, 15), at(16, 8, 0), false},
+	}
+	for _, tt := range tests {
+		got, err := (backfill{db: db}).mergeRecent(intradayRanges["steps"], tt.jobs, tt.now)
+		if err != nil {
+			t.Errorf("%s: mergeRecent error: %v", tt.name, err)
+			continue
+		}
+		if !tt.merged {
+			if len(got) != len(tt.jobs) || !got[0].since.IsZero() {
+				t.Errorf("%s: mergeRecent = %+v, want the jobs by day", tt.name, got)
+			}
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("%s: mergeRecent = %d jobs, want 1", tt.name, len(got))
+			continue
+		}
+		job := got[0]
+		if !job.since.Equal(at(14, 22, 30)) || !job.r.Start.Equal(at(14, 0, 0)) || !job.r.End.Equal(at(15, 0, 0)) {
+			t.Errorf("%s: mergeRecent = %s..%s since %s, want 03-14..03-15 since 22:30",
+				tt.name, job.r.Start, job.r.End, job.since)
+		}
+	}
+}
+
+func TestPendingJobsWithoutReadings(t *testing.T) {
+	db := testDb(t)
+	today := truncateDay(time.Now())
+	yesterday := today.AddDate(0, 0, -1)
+	types, err := selectBackfillTypes([]string{"heart", "sleep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// with no readings stored both types are fetched by day
+	jobs, err := (backfill{db: db, types: types}).pendingJobs(yesterday, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 4 {
+		t.Errorf("pendingJobs = %d jobs, want 4", len(jobs))
+	}
+	for _, job := range jobs {
+		if !job.since.IsZero() {
+			t.Errorf("%s job for %s fetches since %s, want by day", job.typ.name, job.r.Start, job.since)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/NimbleMarkets/ntcharts/barchart"
+	"github.com/NimbleMarkets/ntcharts/canvas"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/ynab"
+)
+
+// categoryReport is one category's numbers for a budget month.
+// Activity is negative for spending.
+type categoryReport struct {
+	Name     string
+	Budgeted ynab.Milliunits
+	Activity ynab.Milliunits
+	Balance  ynab.Milliunits
+}
+
+func (c categoryReport) Overspent() bool {
+	return c.Balance < 0
+}
+
+func (c categoryReport) Spent() ynab.Milliunits {
+	return max(-c.Activity, 0)
+}
+
+type groupReport struct {
+	Name       string
+	Categories []categoryReport
+}
+
+func (g groupReport) Totals() categoryReport {
+	total := categoryReport{Name: g.Name}
+	for _, c := range g.Categories {
+		total.Budgeted += c.Budgeted
+		total.Activity += c.Activity
+		total.Balance += c.Balance
+	}
+	return total
+}
+
+// buildBudgetReport groups a month's categories by category group,
+// in the order of groups, leaving out hidden and deleted categories.
+func buildBudgetReport(groups []ynab.CategoryGroup, month *ynab.Month) []groupReport {
+	byId := make(map[string]ynab.Category)
+	for _, c := range month.Categories {
+		byId[c.Id] = c
+	}
+
+	var report []groupReport
+	for _, g := range groups {
+		if g.Hidden || g.Deleted || g.Name == "Internal Master Category" {
+			continue
+		}
+		gr := groupReport{Name: g.Name}
+		for _, c := range g.Categories {
+			mc, ok := byId[c.Id]
+			if !ok || c.Hidden || c.Deleted {
+				continue
+			}
+			gr.Categories = append(gr.Categories, categoryReport{
+				Name:     mc.Name,
+				Budgeted: mc.Budgeted,
+				Activity: mc.Activity,
+				Balance:  mc.Balance,
+			})
+		}
+		if len(gr.Categories) > 0 {
+			report = append(report, gr)
+		}
+	}
+	return report
+}
+
+// projectSpend extends the spending so far in month to the whole month
+// at the same daily rate. Spending in any month but the current one is
+// returned as is.
+func projectSpend(spent ynab.Milliunits, month, now time.Time) ynab.Milliunits {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	if now.Year() != start.Year() || now.Month() != start.Month() {
+		return spent
+	}
+	days := start.AddDate(0, 1, -1).Day()
+	return ynab.Milliunits(float64(spent) / float64(now.Day()) * float64(days))
+}
+
+// overPace reports whether projected spending is more than the category
+// had available for the month.
+func (c categoryReport) overPace(projected ynab.Milliunits) bool {
+	return projected > c.Spent()+c.Balance
+}
+
+// BudgetView shows the current month's spending per category as
+// horizontal bars, read from the ynab data stored by sync.
+type BudgetView struct {
+	barchart.Model
+	db       *sql.DB
+	month    time.Time
+	report   []groupReport
+	currency ynab.CurrencyFormat
+	spinner  loadingSpinner
+}
+
+// budgetDataMsg carries the report loaded for month.
+type budgetDataMsg struct {
+	month    time.Time
+	report   []groupReport
+	currency ynab.CurrencyFormat
+}
+
+// NewBudgetView returns an empty view of the current month, its data is
+// loaded by Init.
+func NewBudgetView(db *sql.DB, width, height int) BudgetView {
+	now := time.Now()
+	return BudgetView{
+		Model:   barchart.New(width, height, barchart.WithHorizontalBars()),
+		db:      db,
+		month:   time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+		spinner: newLoadingSpinner(),
+	}
+}
+
+// Init starts loading the selected month.
+func (b BudgetView) Init() tea.Cmd {
+	return tea.Batch(b.spinner.Tick, b.load())
+}
+
+// SetDate shows the month containing date's day.
+func (b *BudgetView) SetDate(date viewDate) tea.Cmd {
+	month := time.Date(date.day.Year(), date.day.Month(), 1, 0, 0, 0, 0, time.Local)
+	if month.Equal(b.month) {
+		return nil
+	}
+	b.month = month
+	return tea.Batch(b.spinner.start(), b.load())
+}
+
+func (b BudgetView) load() tea.Cmd {
+	db, month := b.db, b.month
+	return func() tea.Msg {
+		budgetId, currency := storedBudget(db)
+		return budgetDataMsg{
+			month:    month,
+			report:   GetStoredBudgetReport(db, budgetId, month),
+			currency: currency,
+		}
+	}
+}
+
+// SetSize fits the chart, its title and legend into width by height.
+func (b *BudgetView) SetSize(width, height int) {
+	b.Resize(max(width, 1), max(height-2, 1))
+	b.Draw()
+}
+
+// Draw draws the bars, hatching the money available in the mono theme
+// where no colour tells it from the spending.
+func (b *BudgetView) Draw() {
+	b.Model.Draw()
+	if !teaTheme.Mono {
+		return
+	}
+	var spent []float64
+	for _, g := range b.report {
+		for _, c := range g.Categories {
+			spent = append(spent, c.Spent().Float64())
+		}
+	}
+	step := b.BarWidth() + b.BarGap()
+	for y := 0; y < b.Canvas.Height(); y++ {
+		i := y / step
+		if i >= len(spent) || y%step >= b.BarWidth() {
+			continue
+		}
+		// the bar starts at its first block, after the label
+		start := 0
+		for start < b.Canvas.Width() && !isBlock(b.Canvas.Cell(canvas.Point{X: start, Y: y}).Rune) {
+			start++
+		}
+		for x := start + int(math.Round(spent[i]*b.Scale())); x < b.Canvas.Width(); x++ {
+			p := canvas.Point{X: x, Y: y}
+			if !isBlock(b.Canvas.Cell(p).Rune) {
+				break
+			}
+			b.Canvas.SetCell(p, canvas.Cell{Rune: '▒', Style: availableStyle})
+		}
+	}
+}
+
+// isBlock reports whether r is a full block or one of the left blocks
+// ending a bar.
+func isBlock(r rune) bool {
+	return r >= '█' && r <= '▏'
+}
+
+func budgetBars(report []groupReport) []barchart.BarData {
+	var bars []barchart.BarData
+	for _, g := range report {
+		for _, c := range g.Categories {
+			style := spentStyle
+			if c.Overspent() {
+				style = overspentStyle
+			}
+			bars = append(bars, barchart.BarData{
+				Label: c.Name,
+				Values: []barchart.BarValue{
+					{Name: "spent", Value: c.Spent().Float64(), Style: style},
+					{Name: "available", Value: max(c.Balance, 0).Float64(), Style: availableStyle},
+				},
+			})
+		}
+	}
+	return bars
+}
+
+func (b BudgetView) Update(msg tea.Msg) (BudgetView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case budgetDataMsg:
+		if !msg.month.Equal(b.month) {
+			return b, nil
+		}
+		b.spinner.stop()
+		b.currency = msg.currency
+		b.report = msg.report
+		b.Clear()
+		b.PushAll(budgetBars(b.report))
+		b.Draw()
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		b.spinner, cmd = b.spinner.update(msg)
+		return b, cmd
+	}
+	return b, nil
+}
+
+func (b BudgetView) View() string {
+	if len(b.report) == 0 {
+		if b.spinner.loading {
+			return b.spinner.title("loading " + b.month.Format("2006-01"))
+		}
+		return "no budget data for " + b.month.Format("2006-01") + ", run vitus sync"
+	}
+	var total categoryReport
+	for _, g := range b.report {
+		gt := g.Totals()
+		total.Activity += gt.Activity
+		total.Balance += gt.Balance
+	}
+	projected := projectSpend(total.Spent(), b.month, time.Now())
+	spentKey, availableKey := "spent", "available"
+	if teaTheme.Mono {
+		spentKey, availableKey = "█ spent", "▒ available"
+	}
+	legend := fmt.Sprintf("%s  %s %s  %s %s  projected %s",
+		b.month.Format("January 2006"),
+		spentKey,
+		overPaceStyle.Render(b.currency.Format(total.Spent())),
+		availableKey,
+		availableStyle.Render(b.currency.Format(total.Balance)),
+		b.currency.Format(projected),
+	)
+	return lipgloss.JoinVertical(lipgloss.Center, b.spinner.title("spending per category"), legend, b.Model.View())
+}
+
+// storedBudget returns the budget to show from the synced data, either
+// YNAB_BUDGET_ID or the most recently modified budget, and its currency.
+func storedBudget(db *sql.DB) (string, ynab.CurrencyFormat) {
+	var id, currencyJson string
+	var currency ynab.CurrencyFormat
+	query := `SELECT id, currencyFormat FROM YnabBudgets ORDER BY lastModifiedOn DESC LIMIT 1`
+	args := []any{}
+	if envId := os.Getenv("YNAB_BUDGET_ID"); envId != "" {
+		query = `SELECT id, currencyFormat FROM YnabBudgets WHERE id = ?`
+		args = append(args, envId)
+	}
+	err := db.QueryRow(query, args...).Scan(&id, &currencyJson)
+	if err == sql.ErrNoRows {
+		return "", currency
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(currencyJson), &currency); err != nil {
+		log.Fatal(err)
+	}
+	return id, currency
+}
+
+// GetStoredBudgetReport builds the report for a month from synced data.
+// Groups and categories are sorted by name: the YNAB API has no position
+// for them, and a delta sync only returns the ones that changed.
+func GetStoredBudgetReport(db *sql.DB, budgetId string, month time.Time) []groupReport {
+	rows, err := db.Query(
+		`SELECT g.id, g.name, c.name, mc.budgeted, mc.activity, mc.balance
+		FROM YnabMonthCategories mc
+		JOIN YnabCategories c ON c.id = mc.categoryId
+		JOIN YnabCategoryGroups g ON g.id = c.categoryGroupId
+		WHERE mc.budgetId = ? AND mc.month = ?
+			AND NOT c.hidden AND NOT c.deleted AND NOT g.hidden AND NOT g.deleted
+			AND g.name != 'Internal Master Category'
+		ORDER BY g.name COLLATE NOCASE, g.id, c.name COLLATE NOCASE`,
+		budgetId, month.Format("2006-01-02"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	var report []groupReport
+	var lastGroupId string
+	for rows.Next() {
+		var groupId, group string
+		var c categoryReport
+		if err := rows.Scan(&groupId, &group, &c.Name, &c.Budgeted, &c.Activity, &c.Balance); err != nil {
+			log.Fatal(err)
+		}
+		// groups are told apart by id, two may share a name
+		if len(report) == 0 || groupId != lastGroupId {
+			lastGroupId = groupId
+			report = append(report, groupReport{Name: group})
+		}
+		report[len(report)-1].Categories = append(report[len(report)-1].Categories, c)
+	}
+	return report
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetStoredBudgetReport(t *testing.T) {
+	db := testDb(t)
+	for _, q := range []string{
+		`INSERT INTO YnabCategoryGroups (id, budgetId, name, hidden, deleted) VALUES
+			('g1', 'b', 'Bills', 0, 0), ('g2', 'b', 'Bills', 0, 0), ('g3', 'b', 'Fun', 0, 0)`,
+		`INSERT INTO YnabCategories (id, budgetId, categoryGroupId, name, hidden, deleted) VALUES
+			('rent', 'b', 'g1', 'Rent', 0, 0), ('power', 'b', 'g1', 'Power', 0, 0),
+			('phone', 'b', 'g2', 'Phone', 0, 0), ('games', 'b', 'g3', 'Games', 0, 0),
+			('old', 'b', 'g3', 'Old', 0, 1)`,
+		`INSERT INTO YnabMonthCategories (budgetId, month, categoryId, budgeted, activity, balance) VALUES
+			('b', '2024-03-01', 'rent', 1000, -1000, 0), ('b', '2024-03-01', 'power', 100, -50, 50),
+			('b', '2024-03-01', 'phone', 30, -30, 0), ('b', '2024-03-01', 'games', 20, 0, 20),
+			('b', '2024-03-01', 'old', 5, 0, 5), ('b', '2024-02-01', 'rent', 1000, -1000, 0)`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := GetStoredBudgetReport(db, "b", time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local))
+	var groups []string
+	for _, g := range report {
+		var names []string
+		for _, c := range g.Categories {
+			names = append(names, c.Name)
+		}
+		groups = append(groups, fmt.Sprintf("%s: %s", g.Name, strings.Join(names, ", ")))
+	}
+	// groups with the same name stay apart
+	want := "Bills: Power, Rent; Bills: Phone; Fun: Games"
+	if got := strings.Join(groups, "; "); got != want {
+		t.Errorf("GetStoredBudgetReport = %s, want %s", got, want)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/haclark30/vitus/db"
@@ -14,132 +16,636 @@ import (
 )
 
 var createDbCmd = &cobra.Command{
-	Use:   "createdb",
+	Use:   "createdb [from]",
 	Short: "create a new sqlite database from scratch",
-	Args:  cobra.ExactArgs(1),
-	Run:   createDbRun,
+	Long: `Backfill the database from fitbit. Completed days are checkpointed,
+so rerunning after a failure resumes where the last run stopped.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  createDbRun,
+}
+
+var (
+	loadIntraday  bool
+	backfillTypes []string
+	backfillFrom  string
+	backfillTo    string
+	backfillJobs  int
+)
+
+func init() {
+	createDbCmd.Flags().BoolVar(&loadIntraday, "intraday", true,
+		"load minute level data, one request per day per type")
+	createDbCmd.Flags().StringSliceVar(&backfillTypes, "types", nil,
+		"data types to load: "+strings.Join(backfillTypeNames(), ","))
+	addDateRangeFlags(createDbCmd, &backfillFrom, &backfillTo)
+	createDbCmd.Flags().IntVar(&backfillJobs, "workers", 4, "number of concurrent requests")
 }
 
 func createDbRun(cmd *cobra.Command, args []string) {
+	from := backfillFrom
+	if len(args) > 0 {
+		from = args[0]
+	}
+	if from == "" {
+		log.Fatal("a start date or --from is required")
+	}
 	client := fitbit.NewFitbitClient()
+	dates := parseDateRange(from, backfillTo, "", fitbitMemberSince(client))
+
+	types, err := selectBackfillTypes(backfillTypes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !loadIntraday {
+		var daily []backfillType
+		for _, t := range types {
+			if t.days > 1 {
+				daily = append(daily, t)
+			}
+		}
+		types = daily
+	}
+
 	db := db.GetDb()
-	var startDate time.Time
-	switch dateArg := args[0]; dateArg {
-	case "today":
-		startDate = time.Now()
-	case "yesterday":
-		startDate = time.Now().Add(-24 * time.Hour)
-	default:
-		date, err := time.Parse("2006-01-02", dateArg)
+	b := backfill{
+		db:       db,
+		client:   client,
+		types:    types,
+		workers:  backfillJobs,
+		progress: true,
+	}
+	if err := b.run(dates.Start, dates.End); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fetchFunc fetches a range of days from fitbit and returns
+// a function that writes the data to the database.
+type fetchFunc func(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error)
+
+// backfillType is one kind of fitbit data, fetched days at a time.
+type backfillType struct {
+	name  string
+	days  int
+	fetch fetchFunc
+}
+
+var allBackfillTypes = []backfillType{
+	{"weight", 30, fetchWeight},
+	{"daily-steps", 1095, fetchDailySteps},
+	{"heart-summary", 365, fetchHeartSummaries},
+	{"heart", 1, fetchHeartRate},
+	{"steps", 1, fetchSteps},
+	{"sleep", 1, fetchSleep},
+	{"azm", 1, fetchActiveZoneMinutes},
+	{"spo2", 1, fetchSpO2},
+	{"hrv", 1, fetchHrv},
+	{"br", 1, fetchBreathingRate},
+	{"temp", 1, fetchSkinTemp},
+	{"cardio", 1, fetchCardioFitness},
+	{"water", 1095, fetchWater},
+	{"activities", 1, fetchActivities},
+}
+
+// intradayRange fetches an intraday type's minutes for up to 24 hours in
+// a single request, which backfill uses instead of a request per day to
+// catch up on the hours since the last load.
+type intradayRange struct {
+	table  string
+	column string
+	fetch  func(client *http.Client, start, end time.Time) ([]fitbit.IntradayPoint, error)
+}
+
+var intradayRanges = map[string]intradayRange{
+	"heart": {"HeartRateRecords", "heartRate", fitbit.GetHeartIntradayRange},
+	"steps": {"StepsRecords", "steps", fitbit.GetStepsIntradayRange},
+}
+
+// fetchSince returns a fetchFunc that loads the minutes from since to
+// until, whatever range of days it is given.
+func (ir intradayRange) fetchSince(since, until time.Time) fetchFunc {
+	return func(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+		points, err := ir.fetch(client, since, until)
 		if err != nil {
-			log.Fatalf("not a valid date: %s", dateArg)
+			return nil, err
 		}
-		startDate = date
+		return func(txn *sql.Tx) error {
+			stmt, err := txn.Prepare(
+				"INSERT OR REPLACE INTO " + ir.table + " (time, " + ir.column + ") VALUES (?, ?)")
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+
+			for _, p := range points {
+				if _, err = stmt.Exec(p.Time.Unix(), float64(p.Value)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
 	}
-	loadFitbitDb(client, db, startDate)
 }
-func loadWeight(db *sql.DB, client *http.Client, startTime, endTime time.Time) {
-	txn, err := db.Begin()
-	if err != nil {
-		log.Fatal(err)
+
+func backfillTypeNames() []string {
+	var names []string
+	for _, t := range allBackfillTypes {
+		names = append(names, t.name)
 	}
-	stmt, err := txn.Prepare("INSERT OR REPLACE INTO WeightRecords (date, weight) VALUES (?, ?)")
+	return names
+}
+
+// selectBackfillTypes returns the backfill types with the given names,
+// or all of them if no names are given.
+func selectBackfillTypes(names []string) ([]backfillType, error) {
+	if len(names) == 0 {
+		return allBackfillTypes, nil
+	}
+	var types []backfillType
+	for _, n := range names {
+		found := false
+		for _, t := range allBackfillTypes {
+			if t.name == strings.TrimSpace(n) {
+				types = append(types, t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown data type %q, expected one of %s",
+				n, strings.Join(backfillTypeNames(), ","))
+		}
+	}
+	return types, nil
+}
+
+func fetchWeight(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	weightData, err := fitbit.GetWeight(client, r.End, "30d")
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	defer stmt.Close()
+	return func(txn *sql.Tx) error {
+		stmt, err := txn.Prepare("INSERT OR REPLACE INTO WeightRecords (date, weight) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
 
-	for endTime.Compare(startTime) >= 0 {
-		slog.Debug("insert weight", "time", endTime)
-		weightData := fitbit.GetWeight(client, endTime, "30d")
 		for _, w := range weightData.Weight {
-			slog.Debug("insert weight", "time", endTime)
-			_, err = stmt.Exec(w.Date, w.Weight)
+			slog.Debug("insert weight", "time", w.Date)
+			if _, err = stmt.Exec(w.Date, w.Weight); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// fetchDailySteps uses the range endpoint, so a year of totals is a single request.
+func fetchDailySteps(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	stepsData, err := fitbit.GetStepsRange(client, r.Start, r.End)
+	if err != nil {
+		return nil, err
+	}
+	return func(txn *sql.Tx) error {
+		stmt, err := txn.Prepare("INSERT OR REPLACE INTO DailyStepsRecords (date, steps) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, s := range stepsData {
+			steps, err := strconv.ParseFloat(s.Value, 64)
 			if err != nil {
-				log.Fatal(err)
+				return err
+			}
+			if _, err = stmt.Exec(s.DateTime, steps); err != nil {
+				return err
 			}
 		}
-		endTime = endTime.Add(-30 * 24 * time.Hour)
+		return nil
+	}, nil
+}
+
+// fetchWater uses the range endpoint like fetchDailySteps.
+func fetchWater(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	waterData, err := fitbit.GetWaterRange(client, r.Start, r.End)
+	if err != nil {
+		return nil, err
 	}
+	return func(txn *sql.Tx) error {
+		stmt, err := txn.Prepare("INSERT OR REPLACE INTO WaterRecords (date, ounces) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
 
-	if err := txn.Commit(); err != nil {
-		log.Fatal(err)
+		for _, w := range waterData {
+			ounces, err := strconv.ParseFloat(w.Value, 64)
+			if err != nil {
+				return err
+			}
+			if _, err = stmt.Exec(w.DateTime, ounces); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// fetchHeartSummaries loads resting heart rate and heart rate zones
+// using the range endpoint.
+func fetchHeartSummaries(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	heartData, err := fitbit.GetHeartRange(client, r.Start, r.End)
+	if err != nil {
+		return nil, err
 	}
-	slog.Debug("done with weight")
+	return func(txn *sql.Tx) error {
+		for _, day := range heartData {
+			if err := insertHeartDay(txn, day); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
 }
 
-func loadHeartRate(db *sql.DB, client *http.Client, startTime, endTime time.Time) {
-	txn, err := db.Begin()
+func fetchHeartRate(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	hr, err := fitbit.GetHeartDay(client, r.Start)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	return func(txn *sql.Tx) error {
+		if len(hr.ActivitiesHeart) == 0 {
+			return nil
+		}
+		if err := insertHeartDay(txn, hr.ActivitiesHeart[0]); err != nil {
+			return err
+		}
+		return insertIntraday(txn, "HeartRateRecords", "heartRate",
+			hr.ActivitiesHeart[0].DateTime, hr.ActivitiesHeartIntraday.Dataset)
+	}, nil
+}
+
+func fetchSteps(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	stepData, err := fitbit.GetStepsDay(client, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	return func(txn *sql.Tx) error {
+		if len(stepData.ActivitiesSteps) == 0 {
+			return nil
+		}
+		return insertIntraday(txn, "StepsRecords", "steps",
+			stepData.ActivitiesSteps[0].DateTime, stepData.ActivitiesStepsIntra.Dataset)
+	}, nil
+}
+
+// insertIntraday stores a day of minute level readings,
+// whose times are local times on date.
+func insertIntraday(txn *sql.Tx, table, column, date string, dataset []fitbit.IntradayData) error {
+	stmt, err := txn.Prepare(
+		"INSERT OR REPLACE INTO " + table + " (time, " + column + ") VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, d := range dataset {
+		dateTime, err := parseMinute(date + "T" + d.Time)
+		if err != nil {
+			return err
+		}
+		if _, err = stmt.Exec(dateTime.Unix(), float64(d.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertHeartDay(txn *sql.Tx, day fitbit.ActivitiesHeart) error {
+	if day.Value.RestingHeartRate > 0 {
+		_, err := txn.Exec(
+			"INSERT OR REPLACE INTO RestingHeartRecords (date, heartRate) VALUES (?, ?)",
+			day.DateTime, float64(day.Value.RestingHeartRate))
+		if err != nil {
+			return err
+		}
+	}
+
+	stmt, err := txn.Prepare(
+		`INSERT OR REPLACE INTO HeartRateZones
+			(date, name, min, max, minutes, caloriesOut, custom)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, z := range day.Value.HeartRateZones {
+		_, err = stmt.Exec(day.DateTime, z.Name, z.Min, z.Max, z.Minutes, z.CaloriesOut, false)
+		if err != nil {
+			return err
+		}
+	}
+	for _, z := range day.Value.CustomHeartRateZones {
+		_, err = stmt.Exec(day.DateTime, z.Name, z.Min, z.Max, z.Minutes, z.CaloriesOut, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseMinute parses the local timestamps used by fitbit's intraday
+// endpoints, e.g. "2021-10-25T09:10:00" or "2021-10-25T09:10:00.000".
+func parseMinute(minute string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", minute, time.Local)
+	if err != nil {
+		t, err = time.ParseInLocation("2006-01-02T15:04:05.000", minute, time.Local)
+	}
+	return t, err
+}
+
+func fetchSleep(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	sleepData, err := fitbit.GetSleepDay(client, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	sleep, ok := sleepData.MainSleep()
+	return func(txn *sql.Tx) error {
+		if !ok {
+			return nil
+		}
+		start, err := parseMinute(sleep.StartTime)
+		if err != nil {
+			return err
+		}
+		end, err := parseMinute(sleep.EndTime)
+		if err != nil {
+			return err
+		}
+		_, err = txn.Exec(
+			`INSERT OR REPLACE INTO SleepRecords
+				(date, minutesAsleep, timeInBed, efficiency, startTime, endTime)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			sleep.DateOfSleep, sleep.MinutesAsleep, sleep.TimeInBed, sleep.Efficiency,
+			start.Unix(), end.Unix())
+		return err
+	}, nil
+}
+
+// fetchActivities replaces the day's logged activities, so activities
+// deleted on fitbit are dropped too. Duration is in milliseconds.
+func fetchActivities(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	activityData, err := fitbit.GetActivitiesDay(client, r.Start)
+	if err != nil {
+		return nil, err
 	}
-	for endTime.Compare(startTime) >= 0 {
-		slog.Debug("insert heart", "time", endTime)
-		hr := fitbit.GetHeartDay(client, endTime)
-		for _, dayHr := range hr.ActivitiesHeartIntraday.Dataset {
-			stmt, err := txn.Prepare("INSERT OR REPLACE INTO HeartRateRecords (time, heartRate) VALUES (?, ?)")
+	day := r.Start.Format("2006-01-02")
+	return func(txn *sql.Tx) error {
+		if _, err := txn.Exec("DELETE FROM ActivityRecords WHERE date = ?", day); err != nil {
+			return err
+		}
+		stmt, err := txn.Prepare(
+			`INSERT OR REPLACE INTO ActivityRecords
+				(logId, date, startTime, name, duration, calories, steps)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, a := range activityData.Activities {
+			start, err := parseMinute(a.StartDate + "T" + a.StartTime + ":00")
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-			defer stmt.Close()
-
-			date := hr.ActivitiesHeart[0].DateTime
-			tz, _ := time.Now().Zone()
-			dateTime, err := time.Parse("2006-01-02T15:04:05 MST", fmt.Sprintf("%sT%s %s", date, dayHr.Time, tz))
+			_, err = stmt.Exec(a.LogId, day, start.Unix(), a.Name, a.Duration, a.Calories, a.Steps)
 			if err != nil {
-				log.Fatal(err)
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func fetchActiveZoneMinutes(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	azmData, err := fitbit.GetActiveZoneMinutesDay(client, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	return func(txn *sql.Tx) error {
+		stmt, err := txn.Prepare(
+			`INSERT OR REPLACE INTO ActiveZoneMinutesRecords
+				(time, fatBurn, cardio, peak, total)
+			VALUES (?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, azmDay := range azmData.Intraday {
+			for _, m := range azmDay.Minutes {
+				if m.Value.ActiveZoneMinutes == 0 {
+					continue
+				}
+				minute, err := parseMinute(m.Minute)
+				if err != nil {
+					return err
+				}
+				_, err = stmt.Exec(minute.Unix(),
+					m.Value.FatBurnActiveZoneMinutes, m.Value.CardioActiveZoneMinutes,
+					m.Value.PeakActiveZoneMinutes, m.Value.ActiveZoneMinutes)
+				if err != nil {
+					return err
+				}
 			}
-			_, err = stmt.Exec(dateTime.Unix(), float64(dayHr.Value))
+		}
+		return nil
+	}, nil
+}
+
+// skipWrite is the write of a fetch that found nothing to store.
+func skipWrite(txn *sql.Tx) error {
+	return nil
+}
+
+func fetchSpO2(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	spo2, err := fitbit.GetSpO2Day(client, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	if spo2.DateTime == "" {
+		return skipWrite, nil
+	}
+	intraday, err := fitbit.GetSpO2Intraday(client, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	return func(txn *sql.Tx) error {
+		_, err := txn.Exec(
+			"INSERT OR REPLACE INTO SpO2Records (date, avg, min, max) VALUES (?, ?, ?, ?)",
+			spo2.DateTime, spo2.Value.Avg, spo2.Value.Min, spo2.Value.Max)
+		if err != nil {
+			return err
+		}
+
+		stmt, err := txn.Prepare("INSERT OR REPLACE INTO SpO2IntradayRecords (time, spo2) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, m := range intraday.Minutes {
+			minute, err := parseMinute(m.Minute)
 			if err != nil {
-				log.Fatal(err)
+				return err
+			}
+			if _, err = stmt.Exec(minute.Unix(), m.Value); err != nil {
+				return err
 			}
 		}
-		endTime = endTime.Add(-24 * time.Hour)
+		return nil
+	}, nil
+}
+
+func fetchHrv(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	hrv, err := fitbit.GetHrvDay(client, r.Start)
+	if err != nil {
+		return nil, err
 	}
-	if err := txn.Commit(); err != nil {
-		log.Fatal(err)
+	if len(hrv.Hrv) == 0 {
+		return skipWrite, nil
+	}
+	intraday, err := fitbit.GetHrvIntraday(client, r.Start)
+	if err != nil {
+		return nil, err
 	}
-	slog.Debug("done with heart")
+	return func(txn *sql.Tx) error {
+		for _, h := range hrv.Hrv {
+			_, err := txn.Exec(
+				"INSERT OR REPLACE INTO HrvRecords (date, dailyRmssd, deepRmssd) VALUES (?, ?, ?)",
+				h.DateTime, h.Value.DailyRmssd, h.Value.DeepRmssd)
+			if err != nil {
+				return err
+			}
+		}
+
+		stmt, err := txn.Prepare(
+			`INSERT OR REPLACE INTO HrvIntradayRecords
+				(time, rmssd, coverage, hf, lf)
+			VALUES (?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, h := range intraday.Hrv {
+			for _, m := range h.Minutes {
+				minute, err := parseMinute(m.Minute)
+				if err != nil {
+					return err
+				}
+				_, err = stmt.Exec(minute.Unix(),
+					m.Value.Rmssd, m.Value.Coverage, m.Value.Hf, m.Value.Lf)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, nil
 }
 
-func loadSteps(db *sql.DB, client *http.Client, startTime, endTime time.Time) {
-	txn, err := db.Begin()
+func fetchBreathingRate(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	brData, err := fitbit.GetBreathingRateDay(client, r.Start)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	for endTime.Compare(startTime) >= 0 {
-		slog.Debug("insert steps", "time", endTime)
-		stepData := fitbit.GetStepsDay(client, endTime)
-		for _, steps := range stepData.ActivitiesStepsIntra.Dataset {
-			stmt, err := txn.Prepare("INSERT OR REPLACE INTO StepsRecords (time, steps) VALUES (?, ?)")
+	return func(txn *sql.Tx) error {
+		for _, br := range brData.Br {
+			_, err := txn.Exec(
+				"INSERT OR REPLACE INTO BreathingRateRecords (date, breathingRate) VALUES (?, ?)",
+				br.DateTime, br.Value.BreathingRate)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-			defer stmt.Close()
+		}
+		return nil
+	}, nil
+}
 
-			date := stepData.ActivitiesSteps[0].DateTime
-			tz, _ := time.Now().Zone()
+func fetchSkinTemp(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	tempData, err := fitbit.GetSkinTempDay(client, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	return func(txn *sql.Tx) error {
+		for _, t := range tempData.TempSkin {
+			_, err := txn.Exec(
+				"INSERT OR REPLACE INTO SkinTempRecords (date, nightlyRelative) VALUES (?, ?)",
+				t.DateTime, t.Value.NightlyRelative)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
 
-			dateTime, err := time.Parse("2006-01-02T15:04:05 MST", fmt.Sprintf("%sT%s %s", date, steps.Time, tz))
+func fetchCardioFitness(client *http.Client, r fitbit.DateRange) (func(txn *sql.Tx) error, error) {
+	cardioData, err := fitbit.GetCardioScoreDay(client, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	return func(txn *sql.Tx) error {
+		for _, c := range cardioData.CardioScore {
+			low, high, err := c.Vo2MaxRange()
 			if err != nil {
-				log.Fatal(err)
+				slog.Warn("invalid vo2 max", "date", c.DateTime, "vo2Max", c.Value.Vo2Max)
+				continue
 			}
-			_, err = stmt.Exec(dateTime.Unix(), float64(steps.Value))
+			_, err = txn.Exec(
+				"INSERT OR REPLACE INTO CardioFitnessRecords (date, vo2MaxLow, vo2MaxHigh) VALUES (?, ?, ?)",
+				c.DateTime, low, high)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		}
-		endTime = endTime.Add(-24 * time.Hour)
+		return nil
+	}, nil
+}
+
+// loadFitbitDb loads every data type from startTime to endTime, and
+// today's goals when the range runs up to today.
+func loadFitbitDb(client *http.Client, db *sql.DB, startTime, endTime time.Time) error {
+	b := backfill{
+		db:      db,
+		client:  client,
+		types:   allBackfillTypes,
+		workers: 1,
 	}
-	if err := txn.Commit(); err != nil {
-		log.Fatal(err)
+	if err := b.run(startTime, endTime); err != nil {
+		return err
+	}
+	if truncateDay(endTime).Before(truncateDay(time.Now())) {
+		return nil
 	}
-	slog.Debug("done with steps")
+	return loadDailyGoals(client, db, time.Now())
 }
 
-func loadFitbitDb(client *http.Client, db *sql.DB, startTime time.Time) error {
-	loadWeight(db, client, startTime, time.Now())
-	loadHeartRate(db, client, startTime, time.Now())
-	loadSteps(db, client, startTime, time.Now())
-	return nil
+// loadDailyGoals stores the goals set on fitbit as day's goals. Fitbit
+// only has the current goals, so past days keep the goals last loaded
+// on them.
+func loadDailyGoals(client *http.Client, db *sql.DB, day time.Time) error {
+	goals, err := fitbit.GetDailyGoals(client)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		`INSERT OR REPLACE INTO DailyGoals
+			(date, steps, activeZoneMinutes, activeMinutes, caloriesOut, distance)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		day.Format("2006-01-02"), goals.Steps, goals.ActiveZoneMinutes,
+		goals.ActiveMinutes, goals.CaloriesOut, goals.Distance)
+	return err
 }
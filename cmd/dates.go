@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/haclark30/vitus/daterange"
+	"github.com/haclark30/vitus/fitbit"
+	"github.com/spf13/cobra"
+)
+
+const dateRangeHelp = `dates accept YYYY-MM-DD, YYYY-MM, YYYY, YYYY-Qn, YYYY-Www,
+today, yesterday, -30d/-6w/-3m/-1y, this-/last-week, this-/last-month,
+this-/last-year, or A..B with either side left open`
+
+// addDateRangeFlags adds the --from/--to flags shared by every command
+// that works on a range of days.
+func addDateRangeFlags(cmd *cobra.Command, from, to *string) {
+	cmd.Flags().StringVar(from, "from", "", "first day, "+dateRangeHelp)
+	cmd.Flags().StringVar(to, "to", "", "last day, defaults to today")
+}
+
+// parseDateRange parses --from/--to flags, falling back to defaultFrom
+// when --from is not set. Open ends are closed with earliest and today.
+func parseDateRange(from, to, defaultFrom string, earliest time.Time) daterange.Range {
+	r, err := dateRange(from, to, defaultFrom, earliest, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+	return r
+}
+
+// dateRange is parseDateRange with now given, returning errors.
+func dateRange(from, to, defaultFrom string, earliest, now time.Time) (daterange.Range, error) {
+	if from == "" {
+		from = defaultFrom
+	}
+	r, err := daterange.FromTo(from, to, now)
+	if err != nil {
+		return daterange.Range{}, err
+	}
+	return r.Bound(earliest, now)
+}
+
+// fitbitMemberSince returns the day the fitbit account was created.
+func fitbitMemberSince(client *http.Client) time.Time {
+	memberSince, err := getMemberSince(client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return memberSince
+}
+
+func getMemberSince(client *http.Client) (time.Time, error) {
+	profile, err := fitbit.GetProfile(client)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return profile.MemberSince()
+}
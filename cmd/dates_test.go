@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc serves requests with a function, in place of fitbit.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestDateRange(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	memberSince := time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		from, to, defaultFrom string
+		earliest              time.Time
+		want                  string
+	}{
+		{"", "", "today", memberSince, "2024-03-15..2024-03-15"},
+		{"", "", "-30d", time.Time{}, "2024-02-14..2024-03-15"},
+		{"2024-03-01", "2024-03-10", "today", memberSince, "2024-03-01..2024-03-10"},
+		{"-7d", "", "today", memberSince, "2024-03-08..2024-03-15"},
+		// days before the account was created are skipped
+		{"2001-01-01", "", "today", memberSince, "2023-06-01..2024-03-15"},
+		{"", "", "", memberSince, "2023-06-01..2024-03-15"},
+		{"2023", "2023", "today", memberSince, "2023-06-01..2023-12-31"},
+		// and so are days after today
+		{"2024-03", "", "today", memberSince, "2024-03-01..2024-03-15"},
+	}
+	for _, tt := range tests {
+		got, err := dateRange(tt.from, tt.to, tt.defaultFrom, tt.earliest, now)
+		if err != nil {
+			t.Errorf("dateRange(%q, %q, %q) error: %v", tt.from, tt.to, tt.defaultFrom, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("dateRange(%q, %q, %q) = %s, want %s", tt.from, tt.to, tt.defaultFrom, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ from, to string }{
+		{"2001-01-01", "2001-12-31"},
+		{"2024-04-01", ""},
+		{"2024-03-10", "2024-03-01"},
+		{"nonsense", ""},
+	} {
+		if got, err := dateRange(tt.from, tt.to, "today", memberSince, now); err == nil {
+			t.Errorf("dateRange(%q, %q) = %s, want an error", tt.from, tt.to, got)
+		}
+	}
+}
+
+func TestGetMemberSince(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if !strings.HasSuffix(r.URL.Path, "/profile.json") {
+			t.Errorf("requested %s, want the profile", r.URL)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"user": {"memberSince": "2023-06-01"}}`)),
+		}, nil
+	})}
+	got, err := getMemberSince(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("getMemberSince = %s, want %s", got, want)
+	}
+	// the member since day bounds a load from before it
+	r, err := dateRange("2001-01-01", "2023-06-30", "today", got, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "2023-06-01..2023-06-30" {
+		t.Errorf("load from 2001-01-01 = %s, want 2023-06-01..2023-06-30", r)
+	}
+}
@@ -26,7 +26,15 @@ var fitbitCmd = &cobra.Command{
 var fitbitLoadCmd = &cobra.Command{
 	Use:   "load",
 	Short: "load fitbit data to db",
-	Run:   fitbitLoad,
+	Long: `Load fitbit data for a range of days, today by default.
+
+The --days flag has been replaced by --from: where you used --days 30,
+use --from -30d. Whole periods work too, such as --from last-month or
+--from 2024-Q1. Days before the fitbit account was created are skipped.
+
+  vitus fitbit load --from -30d
+  vitus fitbit load --from 2024-03-01 --to 2024-03-15`,
+	Run: fitbitLoad,
 }
 
 var fitbitApiCmd = &cobra.Command{
@@ -44,12 +52,11 @@ var fitbitWaterCmd = &cobra.Command{
 
 var client *http.Client
 
-var loadVal int
+var loadFrom, loadTo string
 
 func init() {
 	dotenv.Load()
-	client = fitbit.NewFitbitClient()
-	fitbitLoadCmd.Flags().IntVar(&loadVal, "days", 30, "days")
+	addDateRangeFlags(fitbitLoadCmd, &loadFrom, &loadTo)
 	fitbitCmd.AddCommand(fitbitLoadCmd)
 	fitbitCmd.AddCommand(fitbitApiCmd)
 	fitbitCmd.AddCommand(fitbitWaterCmd)
@@ -149,7 +156,10 @@ func fitbitRun(cmd *cobra.Command, args []string) {
 
 func fitbitLoad(cmd *cobra.Command, args []string) {
 	db := db.GetDb()
-	loadFitbitDb(client, db, time.Now())
+	dates := parseDateRange(loadFrom, loadTo, "today", fitbitMemberSince(client))
+	if err := loadFitbitDb(client, db, dates.Start, dates.End); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func fitbitApi(cmd *cobra.Command, args []string) {
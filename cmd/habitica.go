@@ -2,28 +2,356 @@ package cmd
 
 import (
 	"fmt"
-	"io"
+	"log"
+	"math"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/daterange"
+	"github.com/haclark30/vitus/db"
 	"github.com/haclark30/vitus/habitica"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	habiticaTasksCmd.Flags().StringVar(&habiticaTaskType, "type", "", "habits, dailys, todos, rewards or completedTodos")
+	habiticaScoreCmd.Flags().StringVar(&habiticaChecklistItem, "checklist", "", "complete this checklist item of the task instead")
+	habiticaAddCmd.Flags().StringVar(&habiticaAddNotes, "notes", "", "notes")
+	habiticaAddCmd.Flags().StringVar(&habiticaAddDue, "due", "", "due date")
+	habiticaAddCmd.Flags().StringVar(&habiticaAddPriority, "priority", "easy", "trivial, easy, medium or hard")
+	habiticaAddCmd.Flags().StringArrayVar(&habiticaAddChecklist, "checklist", nil, "checklist item, repeatable")
+	habiticaCmd.AddCommand(habiticaTasksCmd)
+	habiticaCmd.AddCommand(habiticaScoreCmd)
+	habiticaCmd.AddCommand(habiticaAddCmd)
+	habiticaStatsCmd.Flags().IntVar(&habiticaHeatmapWeeks, "weeks", 26, "weeks of history to show for each task")
+	habiticaCmd.AddCommand(habiticaStatsCmd)
 	rootCmd.AddCommand(habiticaCmd)
 }
 
 var habiticaCmd = &cobra.Command{
 	Use:   "habitica",
 	Short: "habitica stats",
-	Run:   habiticaRun,
 }
 
-func habiticaRun(cmd *cobra.Command, args []string) {
+var habiticaTasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "list habits, dailies, todos and rewards",
+	Run:   habiticaTasks,
+}
+
+var habiticaScoreCmd = &cobra.Command{
+	Use:   "score <task> [up|down]",
+	Short: "score a task up or down",
+	Long: `Score a task, given by id, alias or text, up or down. Scoring a daily or
+todo up completes it. With --checklist a checklist item of the task is
+completed, or uncompleted if it was already, instead.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  habiticaScore,
+}
+
+var habiticaAddCmd = &cobra.Command{
+	Use:   "add <text>",
+	Short: "add a todo",
+	Args:  cobra.ExactArgs(1),
+	Run:   habiticaAdd,
+}
+
+var habiticaStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show health, mana, experience, gold, level and daily and habit history",
+	Run:   habiticaStats,
+}
+
+var (
+	habiticaTaskType      string
+	habiticaChecklistItem string
+	habiticaAddNotes      string
+	habiticaAddDue        string
+	habiticaAddPriority   string
+	habiticaAddChecklist  []string
+	habiticaHeatmapWeeks  int
+)
+
+// habiticaClient returns a client for the user in HABITICA_USER_ID,
+// identifying itself with HABITICA_X_CLIENT if set.
+func habiticaClient() (*http.Client, error) {
+	return habitica.NewHabiticaClient(
+		os.Getenv("HABITICA_USER_ID"), os.Getenv("HABITICA_API_KEY"), os.Getenv("HABITICA_X_CLIENT"))
+}
+
+// newHabiticaClient is habiticaClient for commands, which exit when
+// HABITICA_X_CLIENT is malformed.
+func newHabiticaClient() *http.Client {
+	client, err := habiticaClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+// findTask finds a task by id, alias or case insensitive text, falling
+// back to the only task containing query.
+func findTask(tasks []habitica.Task, query string) (habitica.Task, error) {
+	var partial []habitica.Task
+	for _, t := range tasks {
+		if t.Id == query || (t.Alias != "" && t.Alias == query) || strings.EqualFold(t.Text, query) {
+			return t, nil
+		}
+		if strings.Contains(strings.ToLower(t.Text), strings.ToLower(query)) {
+			partial = append(partial, t)
+		}
+	}
+	switch len(partial) {
+	case 0:
+		return habitica.Task{}, fmt.Errorf("no task %q", query)
+	case 1:
+		return partial[0], nil
+	default:
+		var names []string
+		for _, t := range partial {
+			names = append(names, t.Text)
+		}
+		return habitica.Task{}, fmt.Errorf("%q matches more than one task: %s", query, strings.Join(names, ", "))
+	}
+}
+
+func formatTask(t habitica.Task) string {
+	switch t.Type {
+	case habitica.TypeHabit:
+		counters := ""
+		if t.Up {
+			counters += fmt.Sprintf(" +%d", t.CounterUp)
+		}
+		if t.Down {
+			counters += fmt.Sprintf(" -%d", t.CounterDown)
+		}
+		return t.Text + counters
+	case habitica.TypeDaily:
+		s := checkbox(t.Completed) + " " + t.Text
+		if !t.IsDue {
+			s += " (not due)"
+		}
+		if t.Streak > 0 {
+			s += fmt.Sprintf(" streak %d", t.Streak)
+		}
+		return s + checklistProgress(t)
+	case habitica.TypeTodo:
+		s := checkbox(t.Completed) + " " + t.Text
+		if len(t.Date) >= 10 {
+			s += " due " + t.Date[:10]
+		}
+		return s + checklistProgress(t)
+	case habitica.TypeReward:
+		return fmt.Sprintf("%s (%.0f gold)", t.Text, t.Value)
+	default:
+		return t.Text
+	}
+}
+
+func checkbox(done bool) string {
+	if done {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func checklistProgress(t habitica.Task) string {
+	if len(t.Checklist) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %d/%d", t.ChecklistDone(), len(t.Checklist))
+}
+
+func habiticaTasks(cmd *cobra.Command, args []string) {
+	tasks, err := habitica.GetTasks(newHabiticaClient(), habiticaTaskType)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sections := []struct {
+		title, taskType string
+	}{
+		{"Habits", habitica.TypeHabit},
+		{"Dailies", habitica.TypeDaily},
+		{"Todos", habitica.TypeTodo},
+		{"Rewards", habitica.TypeReward},
+	}
+	first := true
+	for _, section := range sections {
+		var lines []string
+		for _, t := range tasks {
+			if t.Type == section.taskType {
+				lines = append(lines, "  "+formatTask(t))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		fmt.Println(section.title)
+		fmt.Println(strings.Join(lines, "\n"))
+	}
+}
+
+func habiticaScore(cmd *cobra.Command, args []string) {
+	direction := "up"
+	if len(args) == 2 {
+		direction = args[1]
+	}
+	var up bool
+	switch direction {
+	case "up", "+":
+		up = true
+	case "down", "-":
+		up = false
+	default:
+		log.Fatalf("direction must be up or down, not %q", direction)
+	}
+
+	client := newHabiticaClient()
+	tasks, err := habitica.GetTasks(client, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	task, err := findTask(tasks, args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if habiticaChecklistItem != "" {
+		var itemId string
+		for _, item := range task.Checklist {
+			if item.Id == habiticaChecklistItem || strings.EqualFold(item.Text, habiticaChecklistItem) {
+				itemId = item.Id
+			}
+		}
+		if itemId == "" {
+			log.Fatalf("%s has no checklist item %q", task.Text, habiticaChecklistItem)
+		}
+		updated, err := habitica.ScoreChecklistItem(client, task.Id, itemId)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(formatTask(*updated))
+		return
+	}
+
+	before, err := habitica.GetUser(client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := habitica.ScoreTask(client, task.Id, up)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("scored %s %s\n", task.Text, direction)
+	fmt.Printf("hp %+.1f  exp %+.0f  gold %+.2f  mp %+.1f\n",
+		result.Hp-before.Stats.Hp, result.Exp-before.Stats.Exp,
+		result.Gp-before.Stats.Gp, result.Mp-before.Stats.Mp)
+	if result.Lvl > before.Stats.Lvl {
+		fmt.Printf("level up! now level %d\n", result.Lvl)
+	}
+}
+
+func habiticaAdd(cmd *cobra.Command, args []string) {
+	priority, ok := habitica.Priority[strings.ToLower(habiticaAddPriority)]
+	if !ok {
+		log.Fatalf("priority must be trivial, easy, medium or hard, not %q", habiticaAddPriority)
+	}
+	todo := habitica.NewTodo{
+		Text:     args[0],
+		Notes:    habiticaAddNotes,
+		Priority: priority,
+	}
+	if habiticaAddDue != "" {
+		due, err := daterange.Parse(habiticaAddDue, time.Now())
+		if err != nil || due.Start.IsZero() {
+			log.Fatalf("not a valid date: %s", habiticaAddDue)
+		}
+		todo.Date = due.Start.Format("2006-01-02")
+	}
+	for _, item := range habiticaAddChecklist {
+		todo.Checklist = append(todo.Checklist, habitica.ChecklistItem{Text: item})
+	}
+
+	task, err := habitica.CreateTodo(newHabiticaClient(), todo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("added", formatTask(*task))
+}
+
+// statBar draws value out of max as a bar width cells wide.
+func statBar(value, max float64, width int, style lipgloss.Style) string {
+	filled := 0
+	if max > 0 {
+		filled = int(math.Round(math.Max(0, math.Min(value/max, 1)) * float64(width)))
+	}
+	return style.Render(strings.Repeat("█", filled)) + strings.Repeat("░", width-filled)
+}
+
+func habiticaStats(cmd *cobra.Command, args []string) {
+	client := newHabiticaClient()
+	user, err := habitica.GetUser(client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := user.Stats
+	fmt.Printf("%s, level %d %s\n\n", user.Profile.Name, s.Lvl, s.Class)
+	fmt.Printf("%-6s %s %.0f/%.0f\n", "health", statBar(s.Hp, s.MaxHealth, 30, hpStyle), s.Hp, s.MaxHealth)
+	fmt.Printf("%-6s %s %.0f/%.0f\n", "mana", statBar(s.Mp, s.MaxMp, 30, mpStyle), s.Mp, s.MaxMp)
+	fmt.Printf("%-6s %s %.0f/%.0f\n", "exp", statBar(s.Exp, s.ToNextLevel, 30, expStyle), s.Exp, s.ToNextLevel)
+	fmt.Printf("%-6s %s\n", "gold", goldStyle.Render(fmt.Sprintf("%.2f", s.Gp)))
+
+	db := db.GetDb()
+	if err := snapshotHabitica(db, client); err != nil {
+		log.Fatal(err)
+	}
+	today := truncateDay(time.Now())
+	weeks := habiticaHeatmapWeeks
+	from := daterange.WeekStart(today).AddDate(0, 0, -7*(weeks-1))
+	dailies := GetDailyHistory(db, today)
+	// habits are totalled over 90 days as well as drawn for the weeks
+	earliest := today.AddDate(0, 0, -89)
+	if from.Before(earliest) {
+		earliest = from
+	}
+	habits := GetHabitHistory(db, earliest, today)
+	if len(dailies) == 0 && len(habits) == 0 {
+		return
+	}
+
+	w := newTabWriter()
+	if len(dailies) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "DAILY\t30 DAYS\t90 DAYS\tSTREAK\tLONGEST")
+		for _, d := range dailies {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", d.Text, d.rate(0), d.rate(1), d.Streak, d.LongestStreak)
+		}
+	}
+	if len(habits) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "HABIT\t30 DAYS\t90 DAYS")
+		for _, h := range habits {
+			up30, down30 := h.total(today.AddDate(0, 0, -29))
+			up90, down90 := h.total(today.AddDate(0, 0, -89))
+			fmt.Fprintf(w, "%s\t+%d -%d\t+%d -%d\n", h.Text, up30, down30, up90, down90)
+		}
+	}
+	w.Flush()
 
-	habClient := habitica.NewHabiticaClient(os.Getenv("HABITICA_USER_ID"), os.Getenv("HABITICA_API_KEY"))
-	resp, _ := habClient.Get("https://habitica.com/api/v3/tasks/user")
-	defer resp.Body.Close()
-	h, _ := io.ReadAll(resp.Body)
-	fmt.Println(string(h))
+	completion := GetDailyCompletion(db, from, today)
+	for _, d := range dailies {
+		fmt.Printf("\n%s\n%s\n", d.Text, renderHeatmap(completion[d.Id], today, weeks))
+	}
+	for _, h := range habits {
+		fmt.Printf("\n%s\n%s\n", h.Text, renderHeatmap(h.rates(), today, weeks))
+	}
+	fmt.Printf("\n%s\n", heatmapLegend())
 }
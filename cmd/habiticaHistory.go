@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/daterange"
+	"github.com/haclark30/vitus/habitica"
+)
+
+// snapshotHabitica stores the state of every habit, daily and todo for
+// today. Each sync replaces the day's snapshot, so the last sync of a day
+// records how it ended. Habits also get their up and down counters saved.
+func snapshotHabitica(db *sql.DB, client *http.Client) error {
+	tasks, err := habitica.GetTasks(client, "")
+	if err != nil {
+		return err
+	}
+	txn, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+	stmt, err := txn.Prepare(
+		`INSERT OR REPLACE INTO HabiticaTaskSnapshots
+			(taskId, date, type, text, value, isDue, completed, streak)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	habitStmt, err := txn.Prepare(
+		`INSERT OR REPLACE INTO HabiticaHabitSnapshots
+			(taskId, date, text, up, down, frequency, counterUp, counterDown)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer habitStmt.Close()
+
+	today := time.Now().Format("2006-01-02")
+	for _, t := range tasks {
+		if t.Type == habitica.TypeReward {
+			continue
+		}
+		_, err = stmt.Exec(t.Id, today, t.Type, t.Text, t.Value, t.IsDue, t.Completed, t.Streak)
+		if err != nil {
+			return err
+		}
+		if t.Type == habitica.TypeHabit {
+			_, err = habitStmt.Exec(t.Id, today, t.Text, t.Up, t.Down, t.Frequency, t.CounterUp, t.CounterDown)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return txn.Commit()
+}
+
+// dailyHistory is the snapshot history of one daily.
+type dailyHistory struct {
+	Id            string
+	Text          string
+	Due           [2]int // days due in the last 30 and 90 days
+	Completed     [2]int
+	Streak        int
+	LongestStreak int
+}
+
+func (d dailyHistory) rate(i int) string {
+	if d.Due[i] == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(d.Completed[i])/float64(d.Due[i]))
+}
+
+// GetDailyHistory returns the completion of every daily over the last 30
+// and 90 days up to today, with its current and longest streak.
+func GetDailyHistory(db *sql.DB, today time.Time) []dailyHistory {
+	from30 := today.AddDate(0, 0, -29).Format("2006-01-02")
+	from90 := today.AddDate(0, 0, -89).Format("2006-01-02")
+	rows, err := db.Query(
+		`SELECT s.taskId,
+			(SELECT text FROM HabiticaTaskSnapshots WHERE taskId = s.taskId ORDER BY date DESC LIMIT 1),
+			sum(s.isDue AND s.date >= ?), sum(s.isDue AND s.completed AND s.date >= ?),
+			sum(s.isDue AND s.date >= ?), sum(s.isDue AND s.completed AND s.date >= ?),
+			(SELECT streak FROM HabiticaTaskSnapshots WHERE taskId = s.taskId ORDER BY date DESC LIMIT 1),
+			max(s.streak)
+		FROM HabiticaTaskSnapshots s
+		WHERE s.type = ? AND s.date <= ?
+		GROUP BY s.taskId
+		ORDER BY max(s.streak) DESC`,
+		from30, from30, from90, from90, habitica.TypeDaily, today.Format("2006-01-02"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	var history []dailyHistory
+	for rows.Next() {
+		var d dailyHistory
+		err := rows.Scan(&d.Id, &d.Text, &d.Due[0], &d.Completed[0], &d.Due[1], &d.Completed[1],
+			&d.Streak, &d.LongestStreak)
+		if err != nil {
+			log.Fatal(err)
+		}
+		history = append(history, d)
+	}
+	return history
+}
+
+// GetDailyCompletion returns whether each daily was completed on the days
+// it was due from start to end, keyed by task id and date. Days the daily
+// wasn't due or has no snapshot are left out.
+func GetDailyCompletion(db *sql.DB, start, end time.Time) map[string]map[string]float64 {
+	rows, err := db.Query(
+		`SELECT taskId, date(date), completed FROM HabiticaTaskSnapshots
+		WHERE type = ? AND isDue AND date >= ? AND date <= ?`,
+		habitica.TypeDaily, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	completion := make(map[string]map[string]float64)
+	for rows.Next() {
+		var id, date string
+		var completed bool
+		if err := rows.Scan(&id, &date, &completed); err != nil {
+			log.Fatal(err)
+		}
+		if completion[id] == nil {
+			completion[id] = make(map[string]float64)
+		}
+		completion[id][date] = 0
+		if completed {
+			completion[id][date] = 1
+		}
+	}
+	return completion
+}
+
+// habitHistory is the number of times a habit was scored up and down on
+// each day with a snapshot, keyed by date.
+type habitHistory struct {
+	Text     string
+	Up, Down bool
+	Clicks   map[string][2]int
+}
+
+// total returns the up and down clicks from the day from on.
+func (h habitHistory) total(from time.Time) (up, down int) {
+	day := from.Format("2006-01-02")
+	for date, c := range h.Clicks {
+		if date >= day {
+			up += c[0]
+			down += c[1]
+		}
+	}
+	return up, down
+}
+
+// rates returns the clicks of each day as a share of the busiest day,
+// counting up clicks unless the habit can only be scored down.
+func (h habitHistory) rates() map[string]float64 {
+	i := 0
+	if h.Down && !h.Up {
+		i = 1
+	}
+	busiest := 0
+	for _, c := range h.Clicks {
+		busiest = max(busiest, c[i])
+	}
+	rates := make(map[string]float64, len(h.Clicks))
+	for date, c := range h.Clicks {
+		rates[date] = 0
+		if busiest > 0 {
+			rates[date] = float64(c[i]) / float64(busiest)
+		}
+	}
+	return rates
+}
+
+// samePeriod reports whether habit counters of frequency carry over from
+// day a to day b. Daily counters reset every day, weekly ones on Monday
+// and monthly ones on the first.
+func samePeriod(frequency string, a, b time.Time) bool {
+	switch frequency {
+	case "weekly":
+		return daterange.WeekStart(a).Equal(daterange.WeekStart(b))
+	case "monthly":
+		return a.Year() == b.Year() && a.Month() == b.Month()
+	}
+	return false
+}
+
+// GetHabitHistory returns the clicks of every habit from start to end.
+// A day's clicks are its last snapshot's counters, less those of the
+// snapshot before when the counters run over a week or a month.
+func GetHabitHistory(db *sql.DB, start, end time.Time) []habitHistory {
+	rows, err := db.Query(
+		`SELECT taskId, date(date), text, up, down, frequency, counterUp, counterDown
+		FROM HabiticaHabitSnapshots
+		WHERE date >= ? AND date <= ?
+		ORDER BY taskId, date`,
+		// from a month earlier for the snapshots before start in its period
+		start.AddDate(0, -1, 0).Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	var history []habitHistory
+	var lastId string
+	var lastDay time.Time
+	var lastCounters [2]int
+	for rows.Next() {
+		var id, date, text, frequency string
+		var up, down bool
+		var counters [2]int
+		err := rows.Scan(&id, &date, &text, &up, &down, &frequency, &counters[0], &counters[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if id != lastId {
+			history = append(history, habitHistory{Clicks: make(map[string][2]int)})
+		}
+		clicks := counters
+		if id == lastId && samePeriod(frequency, lastDay, day) &&
+			counters[0] >= lastCounters[0] && counters[1] >= lastCounters[1] {
+			clicks = [2]int{counters[0] - lastCounters[0], counters[1] - lastCounters[1]}
+		}
+		// the latest snapshot names the habit
+		last := &history[len(history)-1]
+		last.Text, last.Up, last.Down = text, up, down
+		if !day.Before(start) {
+			last.Clicks[date] = clicks
+		}
+		lastId, lastDay, lastCounters = id, day, counters
+	}
+
+	// drop habits only seen before start
+	kept := history[:0]
+	for _, h := range history {
+		if len(h.Clicks) > 0 {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// heatmapRunes tell the levels of the heatmap apart in the mono theme,
+// least done first.
+var heatmapRunes = []string{"░", "▒", "▓", "█"}
+
+func heatmapLevels() int {
+	if teaTheme.Mono {
+		return len(heatmapRunes)
+	}
+	return len(teaTheme.Heatmap)
+}
+
+func heatmapCell(rate float64, ok bool) string {
+	if !ok {
+		return mutedStyle.Render("·")
+	}
+	if rate == 0 {
+		if teaTheme.Mono {
+			return "□"
+		}
+		return mutedStyle.Render("■")
+	}
+	idx := min(int(rate*float64(heatmapLevels())), heatmapLevels()-1)
+	if teaTheme.Mono {
+		return heatmapRunes[idx]
+	}
+	return lipgloss.NewStyle().Foreground(teaTheme.Heatmap[idx]).Render("■")
+}
+
+// renderHeatmap draws one task's days for the weeks ending with today as
+// a calendar with a row per weekday and a column per week, like GitHub's
+// contribution graph. rates run from 0 to 1, keyed by date.
+func renderHeatmap(rates map[string]float64, today time.Time, weeks int) string {
+	start := daterange.WeekStart(today).AddDate(0, 0, -7*(weeks-1))
+
+	var b strings.Builder
+	// month labels above the first week of each month
+	months := []byte(strings.Repeat(" ", 4+2*weeks))
+	for w := 0; w < weeks; w++ {
+		d := start.AddDate(0, 0, 7*w)
+		if d.Day() <= 7 && 4+2*w+3 <= len(months) {
+			copy(months[4+2*w:], d.Format("Jan"))
+		}
+	}
+	b.WriteString(strings.TrimRight(string(months), " ") + "\n")
+
+	for wd := 0; wd < 7; wd++ {
+		label := "   "
+		if wd%2 == 0 {
+			label = start.AddDate(0, 0, wd).Format("Mon")
+		}
+		b.WriteString(label + " ")
+		for w := 0; w < weeks; w++ {
+			d := start.AddDate(0, 0, 7*w+wd)
+			if d.After(today) {
+				break
+			}
+			rate, ok := rates[d.Format("2006-01-02")]
+			b.WriteString(heatmapCell(rate, ok) + " ")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// heatmapLegend explains the heatmap levels, indented to line up under
+// the heatmaps' weeks.
+func heatmapLegend() string {
+	legend := "less "
+	legend += heatmapCell(0, true) + " "
+	for i := 0; i < heatmapLevels(); i++ {
+		legend += heatmapCell(float64(i+1)/float64(heatmapLevels())-0.01, true) + " "
+	}
+	return strings.Repeat(" ", 4) + legend + "more"
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/habitica"
+)
+
+// habiticaLoadedMsg carries the tasks and user fetched for the Habitica tab.
+type habiticaLoadedMsg struct {
+	tasks []habitica.Task
+	user  *habitica.User
+	err   error
+}
+
+// habiticaScoredMsg is sent when scoring a task or checklist item finished.
+type habiticaScoredMsg struct {
+	text string
+	err  error
+}
+
+// HabiticaView lists the dailies and todos due today. Everything that
+// talks to Habitica runs in a tea.Cmd so the UI never waits on it.
+type HabiticaView struct {
+	client  *http.Client
+	err     error
+	loading bool
+	loaded  bool // the first load finished, tasks may still be empty
+	status  string
+	tasks   []habitica.Task
+	user    *habitica.User
+	cursor  int
+	focused bool
+	width   int
+	height  int
+}
+
+func NewHabiticaView(width, height int) HabiticaView {
+	h := HabiticaView{width: width, height: height}
+	if os.Getenv("HABITICA_API_KEY") == "" {
+		h.err = fmt.Errorf("set HABITICA_USER_ID and HABITICA_API_KEY to see habitica tasks")
+		return h
+	}
+	h.client, h.err = habitica.NewHabiticaClient(
+		os.Getenv("HABITICA_USER_ID"), os.Getenv("HABITICA_API_KEY"), os.Getenv("HABITICA_X_CLIENT"))
+	return h
+}
+
+func (h *HabiticaView) SetSize(width, height int) {
+	h.width = width
+	h.height = height
+}
+
+func (h *HabiticaView) Focus() {
+	h.focused = true
+}
+
+func (h *HabiticaView) Blur() {
+	h.focused = false
+}
+
+func (h HabiticaView) Focused() bool {
+	return h.focused
+}
+
+// Init starts loading the tasks and user.
+func (h HabiticaView) Init() tea.Cmd {
+	if h.client == nil {
+		return nil
+	}
+	return h.load()
+}
+
+func (h HabiticaView) load() tea.Cmd {
+	client := h.client
+	return func() tea.Msg {
+		tasks, err := habitica.GetTasks(client, "")
+		if err != nil {
+			return habiticaLoadedMsg{err: err}
+		}
+		user, err := habitica.GetUser(client)
+		return habiticaLoadedMsg{tasks: dueToday(tasks, time.Now()), user: user, err: err}
+	}
+}
+
+// dueToday returns the dailies due today followed by the todos due
+// today or earlier, soonest first.
+func dueToday(tasks []habitica.Task, now time.Time) []habitica.Task {
+	today := now.Format("2006-01-02")
+	var dailies, todos []habitica.Task
+	for _, t := range tasks {
+		switch {
+		case t.Type == habitica.TypeDaily && t.IsDue:
+			dailies = append(dailies, t)
+		case t.Type == habitica.TypeTodo && len(t.Date) >= 10 && t.Date[:10] <= today:
+			todos = append(todos, t)
+		}
+	}
+	sort.SliceStable(todos, func(i, j int) bool { return todos[i].Date < todos[j].Date })
+	return append(dailies, todos...)
+}
+
+func (h HabiticaView) score(t habitica.Task, up bool) tea.Cmd {
+	client := h.client
+	return func() tea.Msg {
+		_, err := habitica.ScoreTask(client, t.Id, up)
+		return habiticaScoredMsg{text: fmt.Sprintf("scored %s %s", t.Text, directionName(up)), err: err}
+	}
+}
+
+func (h HabiticaView) scoreChecklist(t habitica.Task, item habitica.ChecklistItem) tea.Cmd {
+	client := h.client
+	return func() tea.Msg {
+		_, err := habitica.ScoreChecklistItem(client, t.Id, item.Id)
+		return habiticaScoredMsg{text: "checked " + item.Text, err: err}
+	}
+}
+
+func (h HabiticaView) Update(msg tea.Msg) (HabiticaView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case habiticaLoadedMsg:
+		h.loading = false
+		h.loaded = true
+		h.err = msg.err
+		if msg.err == nil {
+			h.tasks = msg.tasks
+			h.user = msg.user
+			h.cursor = min(h.cursor, max(len(h.tasks)-1, 0))
+		}
+	case habiticaScoredMsg:
+		if msg.err != nil {
+			h.status = msg.err.Error()
+			return h, nil
+		}
+		h.status = msg.text
+		h.loading = true
+		return h, h.load()
+	case tea.KeyMsg:
+		if !h.focused || h.client == nil {
+			return h, nil
+		}
+		switch {
+		case key.Matches(msg, teaKeys.Down):
+			h.cursor = min(h.cursor+1, max(len(h.tasks)-1, 0))
+		case key.Matches(msg, teaKeys.Up):
+			h.cursor = max(h.cursor-1, 0)
+		case key.Matches(msg, teaKeys.Refresh):
+			h.loading = true
+			return h, h.load()
+		}
+		if len(h.tasks) == 0 {
+			return h, nil
+		}
+		t := h.tasks[h.cursor]
+		switch {
+		case key.Matches(msg, teaKeys.Select):
+			// checking a done daily unchecks it
+			h.status = "scoring " + t.Text
+			return h, h.score(t, !t.Completed)
+		case key.Matches(msg, teaKeys.ScoreUp):
+			h.status = "scoring " + t.Text
+			return h, h.score(t, true)
+		case key.Matches(msg, teaKeys.ScoreDown):
+			h.status = "scoring " + t.Text
+			return h, h.score(t, false)
+		case key.Matches(msg, teaKeys.Checklist):
+			for _, item := range t.Checklist {
+				if !item.Completed {
+					h.status = "checking " + item.Text
+					return h, h.scoreChecklist(t, item)
+				}
+			}
+		}
+	}
+	return h, nil
+}
+
+func (h HabiticaView) statusBar() string {
+	if h.user == nil {
+		return ""
+	}
+	s := h.user.Stats
+	barWidth := max(h.width/8, 5)
+	return strings.Join([]string{
+		fmt.Sprintf("lvl %d", s.Lvl),
+		"hp " + statBar(s.Hp, s.MaxHealth, barWidth, hpStyle) + fmt.Sprintf(" %.0f", s.Hp),
+		"xp " + statBar(s.Exp, s.ToNextLevel, barWidth, expStyle) + fmt.Sprintf(" %.0f", s.Exp),
+		"gold " + goldStyle.Render(fmt.Sprintf("%.0f", s.Gp)),
+	}, "   ")
+}
+
+func (h HabiticaView) View() string {
+	var b strings.Builder
+	b.WriteString("habitica, due today\n\n")
+	switch {
+	case h.err != nil:
+		b.WriteString(h.err.Error() + "\n")
+	case !h.loaded:
+		b.WriteString("loading...\n")
+	case len(h.tasks) == 0:
+		b.WriteString("nothing due today\n")
+	default:
+		// keep the cursor in view when there are more tasks than lines
+		lines := max(h.height-5, 1)
+		start := max(0, min(h.cursor-lines/2, len(h.tasks)-lines))
+		end := min(len(h.tasks), start+lines)
+		for i := start; i < end; i++ {
+			line := formatTask(h.tasks[i])
+			if h.tasks[i].Completed {
+				line = completedStyle.Render(line)
+			}
+			if i == h.cursor && h.focused {
+				line = cursorStyle.Render("> ") + line
+			} else {
+				line = "  " + line
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	b.WriteString("\n" + h.statusBar() + "\n")
+	status := h.status
+	if h.loading {
+		status += " (refreshing)"
+	}
+	b.WriteString(status)
+	return lipgloss.NewStyle().Width(h.width).Align(lipgloss.Left).Render(b.String())
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haclark30/vitus/habitica"
+)
+
+func TestDueToday(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	tasks := []habitica.Task{
+		{Text: "later todo", Type: habitica.TypeTodo, Date: "2024-03-20T00:00:00.000Z"},
+		{Text: "habit", Type: habitica.TypeHabit},
+		{Text: "due todo", Type: habitica.TypeTodo, Date: "2024-03-15T00:00:00.000Z"},
+		{Text: "not due daily", Type: habitica.TypeDaily},
+		{Text: "undated todo", Type: habitica.TypeTodo},
+		{Text: "overdue todo", Type: habitica.TypeTodo, Date: "2024-03-01T00:00:00.000Z"},
+		{Text: "daily", Type: habitica.TypeDaily, IsDue: true},
+	}
+	var got []string
+	for _, task := range dueToday(tasks, now) {
+		got = append(got, task.Text)
+	}
+	if want := "daily, overdue todo, due todo"; strings.Join(got, ", ") != want {
+		t.Errorf("dueToday = %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestHabiticaViewNothingDue(t *testing.T) {
+	h := HabiticaView{width: 80, height: 20}
+	if !strings.Contains(h.View(), "loading...") {
+		t.Errorf("View before the first load should say loading:\n%s", h.View())
+	}
+
+	tasks := []habitica.Task{{Text: "later todo", Type: habitica.TypeTodo, Date: "2099-01-01T00:00:00.000Z"}}
+	h, _ = h.Update(habiticaLoadedMsg{tasks: dueToday(tasks, time.Now()), user: &habitica.User{}})
+	view := h.View()
+	if strings.Contains(view, "loading...") || !strings.Contains(view, "nothing due today") {
+		t.Errorf("View with nothing due should say nothing due today:\n%s", view)
+	}
+}
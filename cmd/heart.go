@@ -2,25 +2,69 @@ package cmd
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NimbleMarkets/ntcharts/canvas"
 	"github.com/NimbleMarkets/ntcharts/canvas/runes"
 	"github.com/NimbleMarkets/ntcharts/linechart"
 	tslc "github.com/NimbleMarkets/ntcharts/linechart/timeserieslinechart"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/fitbit"
 )
 
 type HeartChart struct {
 	tslc.Model
 	db           *sql.DB
+	zones        []fitbit.HeartRateZone
+	zoneMinutes  map[string]int
+	spinner      loadingSpinner
+	compact      bool
+	data         []tslc.TimePoint
+	compare      heartComparison
+	baseline     []tslc.TimePoint
+	band         []tslc.TimePoint // both edges of the average's band
+	tooltip      tooltip
 	startDayDiff int
 	endDayDiff   int
 }
 
+// heartComparison is what the day's heart rate is drawn against.
+type heartComparison int
+
+const (
+	compareNone heartComparison = iota
+	compareYesterday
+	compareLastWeek
+	compareAverage
+	numComparisons
+)
+
+// compareDays is the number of days averaged for a comparison.
+const compareDays = 30
+
+// label describes the comparison for the chart showing day.
+func (c heartComparison) label(day time.Time) string {
+	switch c {
+	case compareYesterday:
+		return "day before"
+	case compareLastWeek:
+		return "last " + day.Format("Monday")
+	case compareAverage:
+		return fmt.Sprintf("%d-day average", compareDays)
+	}
+	return ""
+}
+
 func GetHeartData(db *sql.DB, startDayDiff, endDayDiff int) []tslc.TimePoint {
 	stmt, err := db.Prepare(
 		`SELECT
@@ -53,75 +97,409 @@ func GetHeartData(db *sql.DB, startDayDiff, endDayDiff int) []tslc.TimePoint {
 	return timePts
 }
 
+// heartDataMsg carries the readings and zones loaded for the day starting
+// startDayDiff days from today, and those of the comparison moved onto it.
+type heartDataMsg struct {
+	startDayDiff int
+	compare      heartComparison
+	data         []tslc.TimePoint
+	zones        []fitbit.HeartRateZone
+	baseline     []tslc.TimePoint
+	bandLow      []tslc.TimePoint
+	bandHigh     []tslc.TimePoint
+}
+
+// load queries the chart's day in a tea.Cmd.
+func (h HeartChart) load() tea.Cmd {
+	db, start, end, day, compare := h.db, h.startDayDiff, h.endDayDiff, h.day(), h.compare
+	return func() tea.Msg {
+		msg := heartDataMsg{
+			startDayDiff: start,
+			compare:      compare,
+			data:         GetHeartData(db, start, end),
+			zones:        GetHeartZones(db, day),
+		}
+		switch compare {
+		case compareYesterday:
+			msg.baseline = shiftDays(GetHeartData(db, start-1, start), 1)
+		case compareLastWeek:
+			msg.baseline = shiftDays(GetHeartData(db, start-7, start-6), 7)
+		case compareAverage:
+			msg.baseline, msg.bandLow, msg.bandHigh = GetHeartBand(db, day, compareDays)
+		}
+		return msg
+	}
+}
+
+// shiftDays returns points moved days later, keeping their time of day.
+func shiftDays(points []tslc.TimePoint, days int) []tslc.TimePoint {
+	shifted := make([]tslc.TimePoint, len(points))
+	for i, p := range points {
+		shifted[i] = tslc.TimePoint{Time: p.Time.AddDate(0, 0, days), Value: p.Value}
+	}
+	return shifted
+}
+
+// GetHeartBand returns the mean heart rate of every ten minutes of the
+// day over the days before day, and a standard deviation below and above
+// it, as points on day.
+func GetHeartBand(db *sql.DB, day time.Time, days int) (mean, low, high []tslc.TimePoint) {
+	rows, err := db.Query(
+		`SELECT
+			(cast(strftime('%H', time, 'unixepoch', 'localtime') AS INTEGER) * 60 +
+				cast(strftime('%M', time, 'unixepoch', 'localtime') AS INTEGER)) / 10 AS bucket,
+			avg(heartRate), avg(heartRate * heartRate)
+		FROM HeartRateRecords
+		WHERE date(time, 'unixepoch', 'localtime') >= ? AND date(time, 'unixepoch', 'localtime') < ?
+		GROUP BY bucket
+		ORDER BY bucket`,
+		day.AddDate(0, 0, -days).Format("2006-01-02"), day.Format("2006-01-02"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket int
+		var avg, avgSquares float64
+		if err := rows.Scan(&bucket, &avg, &avgSquares); err != nil {
+			log.Fatal(err)
+		}
+		t := day.Add(time.Duration(bucket*10+5) * time.Minute)
+		sd := math.Sqrt(max(avgSquares-avg*avg, 0))
+		mean = append(mean, tslc.TimePoint{Time: t, Value: avg})
+		low = append(low, tslc.TimePoint{Time: t, Value: avg - sd})
+		high = append(high, tslc.TimePoint{Time: t, Value: avg + sd})
+	}
+	return mean, low, high
+}
+
+// cycleCompare moves to the next comparison and reloads the chart.
+func (h *HeartChart) cycleCompare() tea.Cmd {
+	h.compare = (h.compare + 1) % numComparisons
+	return tea.Batch(h.spinner.start(), h.load())
+}
+
+// SetDate shows the readings of date's day, whatever the zoom, as a
+// range of intraday readings is more than the chart can show.
+func (h *HeartChart) SetDate(date viewDate) tea.Cmd {
+	start := dayDiff(date.day)
+	if start == h.startDayDiff {
+		return nil
+	}
+	h.startDayDiff, h.endDayDiff = start, start+1
+	h.setDayRange()
+	return tea.Batch(h.spinner.start(), h.load())
+}
+
+// Init starts loading the chart's day.
+func (h HeartChart) Init() tea.Cmd {
+	return tea.Batch(h.spinner.Tick, h.load())
+}
+
 func (h HeartChart) Update(msg tea.Msg) (HeartChart, tea.Cmd) {
 	switch msg := msg.(type) {
+	case heartDataMsg:
+		if msg.startDayDiff != h.startDayDiff || msg.compare != h.compare {
+			return h, nil
+		}
+		h.spinner.stop()
+		h.Clear()
+		h.ClearAllData()
+		slog.Debug("heart loaded",
+			"startDay", h.startDayDiff,
+			"endDay", h.endDayDiff,
+			"readings", len(msg.data),
+		)
+		for _, t := range msg.data {
+			h.PushDataSet("heart data", t)
+		}
+		for _, t := range msg.baseline {
+			h.PushDataSet("baseline", t)
+		}
+		for i := range msg.bandLow {
+			h.PushDataSet("band low", msg.bandLow[i])
+			h.PushDataSet("band high", msg.bandHigh[i])
+		}
+		h.data = msg.data
+		h.baseline = msg.baseline
+		h.band = append(append([]tslc.TimePoint{}, msg.bandLow...), msg.bandHigh...)
+		h.zones = msg.zones
+		h.zoneMinutes = zoneMinutes(h.zones, msg.data)
+		h.setDayRange()
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		h.spinner, cmd = h.spinner.update(msg)
+		return h, cmd
 	case tea.KeyMsg:
-		switch msg.String() {
-		//TODO: combine repeated code
-		case "down", "j":
-			if h.Focused() {
-				h.startDayDiff--
-				h.endDayDiff--
-				h.Clear()
-				h.ClearAllData()
-				newData := GetHeartData(h.db, h.startDayDiff, h.endDayDiff)
-				slog.Debug("heart down",
-					"startDay", h.startDayDiff,
-					"endDay", h.endDayDiff,
-					"readings", len(newData),
-				)
-				for _, t := range newData {
-					h.PushDataSet("heart data", t)
+		if key.Matches(msg, teaKeys.Compare) {
+			return h, h.cycleCompare()
+		}
+		h.Model, _ = h.Model.Update(msg)
+	case tea.MouseMsg:
+		h.tooltip = tooltip{}
+		if t, x, ok := hoverTime(&h.Model, msg); ok {
+			if p, ok := nearestPoint(h.data, t); ok {
+				text := fmt.Sprintf("%s  %.0f bpm", p.Time.Format("15:04"), p.Value)
+				if b, ok := nearestPoint(h.baseline, t); ok {
+					text += fmt.Sprintf("  %s %.0f", h.compare.label(h.day()), b.Value)
 				}
+				h.tooltip = tooltip{x: x, text: text}
 			}
-		case "up", "k":
-			if h.Focused() {
-				h.startDayDiff++
-				h.endDayDiff++
-				h.Clear()
-				h.ClearAllData()
-				newData := GetHeartData(h.db, h.startDayDiff, h.endDayDiff)
-				slog.Debug("heart up",
-					"startDay", h.startDayDiff,
-					"endDay", h.endDayDiff,
-					"readings", len(newData),
-				)
-				for _, t := range newData {
-					h.PushDataSet("heart data", t)
-				}
+		}
+		if dragging(msg) {
+			h.Model, _ = h.Model.Update(msg)
+		}
+	}
+	return h, nil
+}
+
+// setDayRange makes the chart's x axis span the days it shows.
+func (h *HeartChart) setDayRange() {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	start := today.AddDate(0, 0, h.startDayDiff)
+	end := today.AddDate(0, 0, h.endDayDiff)
+	h.SetTimeRange(start, end)
+	h.SetViewTimeRange(start, end)
+}
+
+// day returns the first day displayed by the chart.
+func (h HeartChart) day() time.Time {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return today.AddDate(0, 0, h.startDayDiff)
+}
+
+func (h HeartChart) Draw() {
+	// the comparison is drawn first so the day's readings are on top of
+	// it, and a data set with no points would stop the drawing
+	var names []string
+	// without colour the comparison is dotted in by drawComparisonRunes
+	if len(h.baseline) > 0 && !teaTheme.Mono {
+		if h.compare == compareAverage {
+			names = append(names, "band low", "band high")
+		}
+		names = append(names, "baseline")
+	}
+	if len(h.data) > 0 {
+		names = append(names, "heart data")
+	}
+	if len(names) > 0 {
+		h.DrawBrailleDataSets(names)
+	} else {
+		h.Clear()
+		h.DrawXYAxisAndLabel()
+	}
+	if teaTheme.Mono {
+		h.drawComparisonRunes()
+	}
+	h.shadeZones()
+	for i, z := range h.zones {
+		if i == 0 {
+			// lowest zone starts at the bottom of the chart
+			continue
+		}
+		from := canvas.Float64Point{X: h.MinX(), Y: float64(z.Min)}
+		to := canvas.Float64Point{X: h.MaxX(), Y: float64(z.Min)}
+		style := lipgloss.NewStyle().Foreground(zoneColor(i))
+		if teaTheme.Mono {
+			h.DrawRuneLineWithStyle(from, to, zoneRune(i), style)
+		} else {
+			h.DrawLineWithStyle(from, to, runes.ArcLineStyle, style)
+		}
+	}
+}
+
+// drawComparisonRunes dots the comparison, and the band of an average,
+// into the cells the day's readings leave empty.
+func (h HeartChart) drawComparisonRunes() {
+	origin := h.Origin()
+	dot := func(points []tslc.TimePoint, r rune, style lipgloss.Style) {
+		for _, pt := range points {
+			sf := h.ScaleFloat64Point(canvas.Float64Point{X: float64(pt.Time.Unix()), Y: pt.Value})
+			p := canvas.CanvasPointFromFloat64Point(origin, sf)
+			if p.X <= origin.X || p.X >= h.Canvas.Width() || p.Y < 0 || p.Y >= origin.Y {
+				continue
+			}
+			if c := h.Canvas.Cell(p).Rune; c == 0 || c == ' ' {
+				h.Canvas.SetCell(p, canvas.Cell{Rune: r, Style: style})
+			}
+		}
+	}
+	dot(h.baseline, '•', heartBaselineStyle)
+	dot(h.band, '·', heartBandStyle)
+}
+
+// shadeZones sets the background of the graph rows
+// covered by each heart rate zone above the lowest one.
+func (h HeartChart) shadeZones() {
+	origin := h.Origin()
+	for i, z := range h.zones {
+		if i == 0 {
+			continue
+		}
+		top := h.zoneRow(float64(z.Max))
+		bottom := h.zoneRow(float64(z.Min))
+		for y := max(top, 0); y <= bottom && y < origin.Y; y++ {
+			for x := origin.X + 1; x < h.Canvas.Width(); x++ {
+				p := canvas.Point{X: x, Y: y}
+				cell := h.Canvas.Cell(p)
+				h.Canvas.SetCellStyle(p, cell.Style.Copy().Background(zoneShade(i)))
 			}
 		}
-		// TODO: clean this shit up
-		now := time.Now()
-		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-		h.SetTimeRange(
-			today.Add(time.Duration(h.startDayDiff*24*int(time.Hour))),
-			today.Add(time.Duration(h.endDayDiff*24*int(time.Hour))),
+	}
+}
+
+// zoneRow returns the canvas row that a heart rate value is drawn on.
+func (h HeartChart) zoneRow(v float64) int {
+	sf := h.ScaleFloat64Point(canvas.Float64Point{X: h.ViewMinX(), Y: v})
+	return canvas.CanvasPointFromFloat64Point(h.Origin(), sf).Y
+}
+
+const heartSideWidth = 16
+
+// SetSize fits the chart and its zones into width by height, leaving the
+// zones out when there is no room beside the chart.
+func (h *HeartChart) SetSize(width, height int) {
+	h.compact = width < compactPanelWidth
+	// the tooltip below the chart, and the date above it when compact
+	if h.compact {
+		h.Resize(max(width, 1), max(height-2, 1))
+	} else {
+		h.Resize(max(width-heartSideWidth, 1), max(height-1, 1))
+	}
+	h.Draw()
+}
+
+func (h HeartChart) View() string {
+	title := h.day().Format("2006-01-02")
+	// the label is in the color of the comparison's line, or by its rune
+	label := h.compare.label(h.day())
+	if teaTheme.Mono {
+		label = "• " + label
+	}
+	if h.compare != compareNone {
+		title += " vs " + heartBaselineStyle.Render(label)
+	}
+	if h.compact {
+		return lipgloss.JoinVertical(lipgloss.Center,
+			h.spinner.title(title),
+			h.Model.View(),
+			h.tooltip.View(h.Model.Width()),
 		)
-		h.SetViewTimeRange(
-			today.Add(time.Duration(h.startDayDiff*24*int(time.Hour))),
-			today.Add(time.Duration(h.endDayDiff*24*int(time.Hour))),
+	}
+	var zoneText strings.Builder
+	zoneText.WriteString(h.spinner.title(h.day().Format("2006-01-02")) + "\n")
+	if h.compare != compareNone {
+		zoneText.WriteString(heartBaselineStyle.Render(label) + "\n")
+	}
+	zoneText.WriteString("\n")
+	for i := len(h.zones) - 1; i >= 0; i-- {
+		z := h.zones[i]
+		mins := h.zoneMinutes[z.Name]
+		zoneText.WriteString(
+			zoneLabel(i, z.Name) +
+				fmt.Sprintf("\n%d-%d bpm\n%dh %02dm\n\n", z.Min, z.Max, mins/60, mins%60),
 		)
 	}
-	return h, nil
+	return lipgloss.JoinHorizontal(lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Left, h.Model.View(), h.tooltip.View(h.Model.Width())),
+		lipgloss.NewStyle().Width(heartSideWidth).PaddingLeft(2).Render(zoneText.String()),
+	)
 }
 
-func (h HeartChart) Draw() {
-	h.DrawBrailleDataSets([]string{"heart data"})
-	h.DrawLine(
-		canvas.Float64Point{X: h.MinX(), Y: 114},
-		canvas.Float64Point{X: h.MaxX(), Y: 114},
-		runes.ArcLineStyle,
+var zoneNames = []string{"Out of Range", "Fat Burn", "Cardio", "Peak"}
+
+// GetHeartZones returns the heart rate zones in effect on the given day,
+// ordered from lowest to highest. Custom zones stored from fitbit take
+// priority over the default fitbit zones, then the HEART_ZONES env var,
+// then the standard fitbit boundaries.
+func GetHeartZones(db *sql.DB, day time.Time) []fitbit.HeartRateZone {
+	stmt, err := db.Prepare(
+		`SELECT name, min, max FROM HeartRateZones
+		WHERE custom = ? AND date = (
+			SELECT max(date) FROM HeartRateZones WHERE custom = ? AND date <= ?)
+		ORDER BY min;`,
 	)
-	h.DrawLine(
-		canvas.Float64Point{X: h.MinX(), Y: 139},
-		canvas.Float64Point{X: h.MaxX(), Y: 139},
-		runes.ArcLineStyle)
-	h.DrawLine(
-		canvas.Float64Point{X: h.MinX(), Y: 170},
-		canvas.Float64Point{X: h.MaxX(), Y: 170},
-		runes.ArcLineStyle)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stmt.Close()
+
+	for _, custom := range []bool{true, false} {
+		rows, err := stmt.Query(custom, custom, day.Format("2006-01-02"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		var zones []fitbit.HeartRateZone
+		for rows.Next() {
+			var z fitbit.HeartRateZone
+			if err := rows.Scan(&z.Name, &z.Min, &z.Max); err != nil {
+				log.Fatal(err)
+			}
+			zones = append(zones, z)
+		}
+		rows.Close()
+		if len(zones) > 0 {
+			return zones
+		}
+	}
+
+	if zones, err := parseHeartZones(os.Getenv("HEART_ZONES")); err == nil {
+		return zones
+	} else if os.Getenv("HEART_ZONES") != "" {
+		slog.Warn("invalid HEART_ZONES", "err", err)
+	}
+	zones, _ := parseHeartZones("114,139,170")
+	return zones
+}
+
+// parseHeartZones builds zones from a comma separated list of ascending
+// lower bounds, e.g. "114,139,170" for fat burn, cardio and peak.
+func parseHeartZones(s string) ([]fitbit.HeartRateZone, error) {
+	if s == "" {
+		return nil, fmt.Errorf("no heart zones given")
+	}
+	bounds := []int{30}
+	for _, b := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(b))
+		if err != nil {
+			return nil, err
+		}
+		if v <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("heart zones must be ascending: %s", s)
+		}
+		bounds = append(bounds, v)
+	}
+	bounds = append(bounds, 220)
+
+	var zones []fitbit.HeartRateZone
+	for i := 0; i < len(bounds)-1; i++ {
+		name := fmt.Sprintf("Zone %d", i)
+		if i < len(zoneNames) {
+			name = zoneNames[i]
+		}
+		zones = append(zones, fitbit.HeartRateZone{Name: name, Min: bounds[i], Max: bounds[i+1]})
+	}
+	return zones, nil
 }
+
+// zoneMinutes totals the minutes of intraday readings that fall in each zone.
+func zoneMinutes(zones []fitbit.HeartRateZone, data []tslc.TimePoint) map[string]int {
+	mins := make(map[string]int)
+	for _, p := range data {
+		for _, z := range zones {
+			if p.Value >= float64(z.Min) && p.Value < float64(z.Max) {
+				mins[z.Name]++
+				break
+			}
+		}
+	}
+	return mins
+}
+
 func LocalHourLabelFormatter() linechart.LabelFormatter {
 	return func(i int, v float64) string {
 		t := time.Unix(int64(v), 0).Local()
@@ -130,23 +508,31 @@ func LocalHourLabelFormatter() linechart.LabelFormatter {
 	}
 }
 
+// NewHeartChart returns an empty chart, its data is loaded by Init.
 func NewHeartChart(db *sql.DB, width, height, startDayDiff, endDayDiff int) HeartChart {
-	dataSet := GetHeartData(db, startDayDiff, endDayDiff)
 	chart := tslc.New(
 		width,
 		height,
-		tslc.WithDataSetTimeSeries("heart data", dataSet),
 		tslc.WithXLabelFormatter(LocalHourLabelFormatter()),
 		tslc.WithYRange(50, 175),
+		// the chart shows a day, so scroll and drag by the hour
+		tslc.WithUpdateHandler(tslc.HourUpdateHandler(1)),
 	)
 	chart.AutoMaxX = false
 	chart.AutoMinX = false
-	chart.SetStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("11")))
+	chart.Canvas.KeyMap = teaKeys.canvasKeyMap()
+	chart.SetStyle(heartStyle)
+	chart.SetDataSetStyle("baseline", heartBaselineStyle)
+	chart.SetDataSetStyle("band low", heartBandStyle)
+	chart.SetDataSetStyle("band high", heartBandStyle)
 
-	return HeartChart{
+	h := HeartChart{
 		Model:        chart,
 		db:           db,
-		startDayDiff: 0,
-		endDayDiff:   1,
+		spinner:      newLoadingSpinner(),
+		startDayDiff: startDayDiff,
+		endDayDiff:   endDayDiff,
 	}
+	h.setDayRange()
+	return h
 }
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/habitica"
+	"github.com/haclark30/vitus/ynab"
+)
+
+// todaySummary is everything the Today tab shows, read from the db.
+// Values that have not been recorded are NaN.
+type todaySummary struct {
+	Steps           float64
+	ZoneMinutes     float64
+	RestingHeart    float64
+	MinutesAsleep   float64
+	SleepEfficiency float64
+	Weight          float64
+	WeightChange    float64 // since a week before the latest weight
+	Water           float64
+	DailiesDue      int
+	DailiesDone     int
+	ToBeBudgeted    ynab.Milliunits
+	SpentToday      ynab.Milliunits
+	Currency        ynab.CurrencyFormat
+	HasBudget       bool
+	HasHabitica     bool
+	StepsGoal       float64
+	ZoneMinuteGoal  float64
+}
+
+// overviewDataMsg carries the summary loaded for the Today tab.
+type overviewDataMsg struct {
+	summary todaySummary
+}
+
+// jumpTabMsg asks the model to switch to a tab.
+type jumpTabMsg activeState
+
+// overviewCard is one card of the Today tab, opening tab when selected.
+type overviewCard struct {
+	title string
+	body  string
+	tab   activeState
+}
+
+// OverviewView shows a card per source summarising today. The selected
+// card opens its detail tab with enter.
+type OverviewView struct {
+	db       *sql.DB
+	summary  todaySummary
+	selected int
+	focused  bool
+	width    int
+	height   int
+	spinner  loadingSpinner
+}
+
+// NewOverviewView returns an empty Today tab, its data is loaded by Init.
+func NewOverviewView(db *sql.DB, width, height int) OverviewView {
+	return OverviewView{
+		db:      db,
+		width:   width,
+		height:  height,
+		spinner: newLoadingSpinner(),
+		summary: emptySummary(),
+	}
+}
+
+// emptySummary is a summary with nothing recorded.
+func emptySummary() todaySummary {
+	nan := math.NaN()
+	return todaySummary{
+		Steps:           nan,
+		ZoneMinutes:     nan,
+		RestingHeart:    nan,
+		MinutesAsleep:   nan,
+		SleepEfficiency: nan,
+		Weight:          nan,
+		WeightChange:    nan,
+		Water:           nan,
+	}
+}
+
+func (o *OverviewView) SetSize(width, height int) {
+	o.width = width
+	o.height = height
+}
+
+func (o *OverviewView) Focus() {
+	o.focused = true
+}
+
+func (o *OverviewView) Blur() {
+	o.focused = false
+}
+
+func (o OverviewView) Focused() bool {
+	return o.focused
+}
+
+// Init starts loading today's summary.
+func (o OverviewView) Init() tea.Cmd {
+	return tea.Batch(o.spinner.Tick, o.load())
+}
+
+func (o OverviewView) load() tea.Cmd {
+	db := o.db
+	return func() tea.Msg {
+		return overviewDataMsg{summary: GetTodaySummary(db, time.Now())}
+	}
+}
+
+// setHabiticaTasks counts today's dailies from the Habitica tab's tasks,
+// which are fresher than the last snapshot.
+func (o *OverviewView) setHabiticaTasks(tasks []habitica.Task) {
+	if tasks == nil {
+		return
+	}
+	o.summary.HasHabitica = true
+	o.summary.DailiesDue, o.summary.DailiesDone = 0, 0
+	for _, t := range tasks {
+		if t.Type == habitica.TypeDaily && t.IsDue {
+			o.summary.DailiesDue++
+			if t.Completed {
+				o.summary.DailiesDone++
+			}
+		}
+	}
+}
+
+func (o OverviewView) Update(msg tea.Msg) (OverviewView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case overviewDataMsg:
+		o.spinner.stop()
+		habitica := o.summary
+		o.summary = msg.summary
+		if habitica.HasHabitica && !msg.summary.HasHabitica {
+			o.summary.HasHabitica = true
+			o.summary.DailiesDue = habitica.DailiesDue
+			o.summary.DailiesDone = habitica.DailiesDone
+		}
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		o.spinner, cmd = o.spinner.update(msg)
+		return o, cmd
+	case tea.KeyMsg:
+		if !o.focused {
+			return o, nil
+		}
+		cols := o.columns()
+		cards := len(o.cards())
+		switch {
+		case key.Matches(msg, teaKeys.Right):
+			o.selected = min(o.selected+1, cards-1)
+		case key.Matches(msg, teaKeys.Left):
+			o.selected = max(o.selected-1, 0)
+		case key.Matches(msg, teaKeys.Down):
+			if o.selected+cols < cards {
+				o.selected += cols
+			}
+		case key.Matches(msg, teaKeys.Up):
+			if o.selected-cols >= 0 {
+				o.selected -= cols
+			}
+		case key.Matches(msg, teaKeys.Select):
+			tab := o.cards()[o.selected].tab
+			return o, func() tea.Msg { return jumpTabMsg(tab) }
+		case key.Matches(msg, teaKeys.Refresh):
+			return o, tea.Batch(o.spinner.start(), o.load())
+		}
+	}
+	return o, nil
+}
+
+const overviewCardWidth = 28
+
+var cardTitleStyle = lipgloss.NewStyle().Bold(true)
+
+func (o OverviewView) columns() int {
+	return max(o.width/overviewCardWidth, 1)
+}
+
+// progressLine renders value against goal as a bar with the numbers below.
+func progressLine(value, goal float64, unit string, style lipgloss.Style) string {
+	if math.IsNaN(value) {
+		return mutedStyle.Render("no data yet")
+	}
+	width := overviewCardWidth - 4
+	if goal <= 0 {
+		return fmt.Sprintf("%.0f %s", value, unit)
+	}
+	return statBar(value, goal, width, style) + "\n" +
+		fmt.Sprintf("%.0f / %.0f %s", value, goal, unit)
+}
+
+func (o OverviewView) cards() []overviewCard {
+	s := o.summary
+	weight := mutedStyle.Render("no data yet")
+	if !math.IsNaN(s.Weight) {
+		weight = fmt.Sprintf("%.1f", s.Weight)
+		if !math.IsNaN(s.WeightChange) {
+			weight += fmt.Sprintf("  %s %+.1f this week", trendArrow(s.WeightChange), s.WeightChange)
+		}
+	}
+
+	water := mutedStyle.Render("no data yet")
+	if !math.IsNaN(s.Water) {
+		water = fmt.Sprintf("%.0f oz", s.Water)
+	}
+
+	restingHeart := mutedStyle.Render("no data yet")
+	if !math.IsNaN(s.RestingHeart) {
+		restingHeart = fmt.Sprintf("%.0f bpm", s.RestingHeart)
+	}
+
+	// the public api has no sleep score, efficiency is the closest to it
+	sleep := formatSleep(s.MinutesAsleep, math.NaN())
+	if !math.IsNaN(s.SleepEfficiency) {
+		sleep += fmt.Sprintf("\nefficiency %.0f%%", s.SleepEfficiency)
+	}
+
+	dailies := mutedStyle.Render("habitica not set up")
+	if s.HasHabitica {
+		dailies = progressLine(float64(s.DailiesDone), float64(s.DailiesDue), "done", goodStyle)
+		if s.DailiesDue == 0 {
+			dailies = "nothing due today"
+		}
+	}
+
+	budget := mutedStyle.Render("no budget synced")
+	if s.HasBudget {
+		budget = "to be budgeted " + s.Currency.Format(s.ToBeBudgeted) +
+			"\nspent today " + s.Currency.Format(s.SpentToday)
+		if s.ToBeBudgeted < 0 {
+			budget = overspentStyle.Render(budget)
+		}
+	}
+
+	return []overviewCard{
+		{"steps", progressLine(s.Steps, s.StepsGoal, "steps", goodStyle), stepsActive},
+		{"active zone minutes", progressLine(s.ZoneMinutes, s.ZoneMinuteGoal, "min", goodStyle), heartActive},
+		{"resting heart rate", restingHeart, heartActive},
+		{"last night's sleep", sleep, recoveryActive},
+		{"weight", weight, weightActive},
+		// there is no water tab, its card opens the recovery tab's day
+		{"water", water, recoveryActive},
+		{"habitica dailies", dailies, habiticaActive},
+		{"budget", budget, budgetActive},
+	}
+}
+
+func trendArrow(change float64) string {
+	switch {
+	case change > 0:
+		return "↑"
+	case change < 0:
+		return "↓"
+	}
+	return "→"
+}
+
+func (o OverviewView) View() string {
+	cards := o.cards()
+	cols := o.columns()
+	var rows []string
+	for start := 0; start < len(cards); start += cols {
+		var row []string
+		for i := start; i < min(start+cols, len(cards)); i++ {
+			style := cardStyle
+			if i == o.selected && o.focused {
+				style = selectedCardStyle
+			}
+			row = append(row, style.Render(cardTitleStyle.Render(cards[i].title)+"\n"+cards[i].body))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	}
+
+	var b strings.Builder
+	b.WriteString(o.spinner.title(time.Now().Format("Monday, January 2")) + "\n\n")
+	b.WriteString(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	b.WriteString("\n" + mutedStyle.Render("enter open  r refresh"))
+	return b.String()
+}
+
+// GetTodaySummary reads the data for the Today tab from the db.
+func GetTodaySummary(db *sql.DB, now time.Time) todaySummary {
+	today := truncateDay(now)
+	date := today.Format("2006-01-02")
+	metrics, err := getRuleMetrics(db, today)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := emptySummary()
+	s.Steps = valueOrNaN(metrics, "steps")
+	s.ZoneMinutes = valueOrNaN(metrics, "azm")
+	s.Water = valueOrNaN(metrics, "water")
+	s.MinutesAsleep = valueOrNaN(metrics, "sleep")
+	s.RestingHeart = valueOrNaN(getDailyValues(db, "RestingHeartRecords", "heartRate", today, today), date)
+	s.SleepEfficiency = valueOrNaN(getDailyValues(db, "SleepRecords", "efficiency", today, today), date)
+
+	// the goals last synced, there are none until the first sync
+	err = db.QueryRow(
+		`SELECT steps, activeZoneMinutes FROM DailyGoals WHERE date <= ? ORDER BY date DESC LIMIT 1`,
+		date).Scan(&s.StepsGoal, &s.ZoneMinuteGoal)
+	if err != nil && err != sql.ErrNoRows {
+		log.Fatal(err)
+	}
+
+	var weightDate string
+	err = db.QueryRow(`SELECT date(date), weight FROM WeightRecords ORDER BY date DESC LIMIT 1`).
+		Scan(&weightDate, &s.Weight)
+	if err != nil && err != sql.ErrNoRows {
+		log.Fatal(err)
+	}
+	if err == nil {
+		var before sql.NullFloat64
+		err := db.QueryRow(
+			`SELECT weight FROM WeightRecords WHERE date(date) <= date(?, '-7 day') ORDER BY date DESC LIMIT 1`,
+			weightDate).Scan(&before)
+		if err != nil && err != sql.ErrNoRows {
+			log.Fatal(err)
+		}
+		if before.Valid {
+			s.WeightChange = s.Weight - before.Float64
+		}
+	}
+
+	err = db.QueryRow(
+		`SELECT count(*), coalesce(sum(completed), 0) FROM HabiticaTaskSnapshots
+		WHERE type = ? AND isDue AND date = ?`,
+		habitica.TypeDaily, date).Scan(&s.DailiesDue, &s.DailiesDone)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.HasHabitica = s.DailiesDue > 0
+
+	budgetId, currency := storedBudget(db)
+	if budgetId != "" {
+		s.HasBudget = true
+		s.Currency = currency
+		month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local)
+		err := db.QueryRow(
+			`SELECT toBeBudgeted FROM YnabMonths WHERE budgetId = ? AND month = ?`,
+			budgetId, month.Format("2006-01-02")).Scan(&s.ToBeBudgeted)
+		if err != nil && err != sql.ErrNoRows {
+			log.Fatal(err)
+		}
+		err = db.QueryRow(
+			`SELECT coalesce(-sum(amount), 0) FROM YnabTransactions
+			WHERE budgetId = ? AND date = ? AND amount < 0 AND coalesce(transferAccountId, '') = '' AND NOT deleted`,
+			budgetId, date).Scan(&s.SpentToday)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return s
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const recoveryBaselineDays = 30
+
+// RecoveryDay holds the overnight metrics that go into a readiness score.
+// Missing metrics are NaN.
+type RecoveryDay struct {
+	Date             time.Time
+	Hrv              float64
+	HrvBaseline      float64
+	RestingHeart     float64
+	RestingBaseline  float64
+	SpO2             float64
+	MinutesAsleep    float64
+	SleepEfficiency  float64
+	BreathingRate    float64
+	SkinTempRelative float64
+}
+
+// Readiness combines the day's metrics into a 0-100 score. HRV and resting
+// heart rate are scored against their baseline, sleep against 8 hours and
+// SpO2 against a 90-100% range. Missing metrics are left out of the average.
+func (r RecoveryDay) Readiness() float64 {
+	var scores []float64
+	if !math.IsNaN(r.Hrv) && r.HrvBaseline > 0 {
+		scores = append(scores, clampScore(50+250*(r.Hrv/r.HrvBaseline-1)))
+	}
+	if !math.IsNaN(r.RestingHeart) && r.RestingBaseline > 0 {
+		scores = append(scores, clampScore(50-500*(r.RestingHeart/r.RestingBaseline-1)))
+	}
+	if !math.IsNaN(r.MinutesAsleep) {
+		scores = append(scores, clampScore(100*r.MinutesAsleep/480))
+	}
+	if !math.IsNaN(r.SpO2) {
+		scores = append(scores, clampScore(12.5*(r.SpO2-90)))
+	}
+	if len(scores) == 0 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, s := range scores {
+		sum += s
+	}
+	return sum / float64(len(scores))
+}
+
+func clampScore(s float64) float64 {
+	return math.Max(0, math.Min(100, s))
+}
+
+type RecoveryView struct {
+	db      *sql.DB
+	date    time.Time
+	days    []RecoveryDay // oldest first, last entry is the selected day
+	focused bool
+	width   int
+	spinner loadingSpinner
+}
+
+// recoveryDataMsg carries the week of recovery days ending on date.
+type recoveryDataMsg struct {
+	date time.Time
+	days []RecoveryDay
+}
+
+// NewRecoveryView returns an empty view for today, its data is loaded by
+// Init.
+func NewRecoveryView(db *sql.DB, width int) RecoveryView {
+	return RecoveryView{db: db, width: width, date: truncateDay(time.Now()), spinner: newLoadingSpinner()}
+}
+
+// Init starts loading the selected week.
+func (r RecoveryView) Init() tea.Cmd {
+	return tea.Batch(r.spinner.Tick, r.load())
+}
+
+func (r RecoveryView) load() tea.Cmd {
+	db, date := r.db, r.date
+	return func() tea.Msg {
+		return recoveryDataMsg{date: date, days: GetRecoveryDays(db, date, 7)}
+	}
+}
+
+// SetDate shows the week ending on date's day.
+func (r *RecoveryView) SetDate(date viewDate) tea.Cmd {
+	if date.day.Equal(r.date) {
+		return nil
+	}
+	r.date = date.day
+	return tea.Batch(r.spinner.start(), r.load())
+}
+
+func (r *RecoveryView) SetSize(width, height int) {
+	r.width = width
+}
+
+func (r *RecoveryView) Focus() {
+	r.focused = true
+}
+
+func (r *RecoveryView) Blur() {
+	r.focused = false
+}
+
+func (r RecoveryView) Focused() bool {
+	return r.focused
+}
+
+func (r RecoveryView) Update(msg tea.Msg) (RecoveryView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case recoveryDataMsg:
+		if msg.date.Equal(r.date) {
+			r.spinner.stop()
+			r.days = msg.days
+		}
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		r.spinner, cmd = r.spinner.update(msg)
+		return r, cmd
+	}
+	return r, nil
+}
+
+func (r RecoveryView) View() string {
+	if len(r.days) == 0 {
+		if r.spinner.loading {
+			return r.spinner.title("loading recovery")
+		}
+		return "no recovery data"
+	}
+	today := r.days[len(r.days)-1]
+	readiness := today.Readiness()
+
+	var b strings.Builder
+	b.WriteString(r.spinner.title("readiness") + "\n" + today.Date.Format("2006-01-02") + "\n\n")
+	if math.IsNaN(readiness) {
+		b.WriteString("no data for this day\n\n")
+	} else {
+		b.WriteString(readinessBar(readiness, r.width/2) + fmt.Sprintf(" %3.0f\n\n", readiness))
+	}
+
+	rows := [][2]string{
+		{"HRV", metricWithBaseline(today.Hrv, today.HrvBaseline, "%.1f ms")},
+		{"resting HR", metricWithBaseline(today.RestingHeart, today.RestingBaseline, "%.0f bpm")},
+		{"SpO2", formatMetric(today.SpO2, "%.1f%%")},
+		{"sleep", formatSleep(today.MinutesAsleep, today.SleepEfficiency)},
+		{"breathing rate", formatMetric(today.BreathingRate, "%.1f brpm")},
+		{"skin temp", formatMetric(today.SkinTempRelative, "%+.1f°")},
+	}
+	for _, row := range rows {
+		b.WriteString(lipgloss.NewStyle().Width(16).Render(row[0]) + row[1] + "\n")
+	}
+
+	b.WriteString("\nlast 7 days\n")
+	var trend []string
+	for _, d := range r.days {
+		trend = append(trend, lipgloss.JoinVertical(lipgloss.Center,
+			readinessBlock(d.Readiness()),
+			d.Date.Format("Mon"),
+		))
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Bottom, trend...))
+
+	return lipgloss.NewStyle().Width(r.width).Align(lipgloss.Left).Render(b.String())
+}
+
+func readinessColor(score float64) lipgloss.TerminalColor {
+	switch {
+	case score >= 67:
+		return teaTheme.Good
+	case score >= 34:
+		return teaTheme.Warn
+	default:
+		return teaTheme.Bad
+	}
+}
+
+func readinessBar(score float64, width int) string {
+	filled := int(score / 100 * float64(width))
+	return lipgloss.NewStyle().Foreground(readinessColor(score)).Render(strings.Repeat("█", filled)) +
+		strings.Repeat("░", width-filled)
+}
+
+var blockRunes = []rune("▁▂▃▄▅▆▇█")
+
+func readinessBlock(score float64) string {
+	if math.IsNaN(score) {
+		return " · "
+	}
+	idx := int(score / 100 * float64(len(blockRunes)-1))
+	return lipgloss.NewStyle().Foreground(readinessColor(score)).Render(" " + string(blockRunes[idx]) + " ")
+}
+
+func formatMetric(v float64, format string) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
+
+func metricWithBaseline(v, baseline float64, format string) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	s := fmt.Sprintf(format, v)
+	if baseline > 0 {
+		s += fmt.Sprintf(" (%+.0f%% vs %d day avg)", 100*(v/baseline-1), recoveryBaselineDays)
+	}
+	return s
+}
+
+func formatSleep(minutes, efficiency float64) string {
+	if math.IsNaN(minutes) {
+		return "-"
+	}
+	s := fmt.Sprintf("%dh %02dm", int(minutes)/60, int(minutes)%60)
+	if !math.IsNaN(efficiency) {
+		s += fmt.Sprintf(" (%.0f%% efficiency)", efficiency)
+	}
+	return s
+}
+
+// GetRecoveryDays returns the recovery metrics for the n days ending on day.
+func GetRecoveryDays(db *sql.DB, day time.Time, n int) []RecoveryDay {
+	start := day.AddDate(0, 0, -(n - 1))
+	hrv := getDailyValues(db, "HrvRecords", "dailyRmssd", start, day)
+	rhr := getDailyValues(db, "RestingHeartRecords", "heartRate", start, day)
+	spo2 := getDailyValues(db, "SpO2Records", "avg", start, day)
+	asleep := getDailyValues(db, "SleepRecords", "minutesAsleep", start, day)
+	efficiency := getDailyValues(db, "SleepRecords", "efficiency", start, day)
+	br := getDailyValues(db, "BreathingRateRecords", "breathingRate", start, day)
+	temp := getDailyValues(db, "SkinTempRecords", "nightlyRelative", start, day)
+
+	var days []RecoveryDay
+	for d := start; !d.After(day); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		days = append(days, RecoveryDay{
+			Date:             d,
+			Hrv:              valueOrNaN(hrv, date),
+			HrvBaseline:      getDailyAverage(db, "HrvRecords", "dailyRmssd", d.AddDate(0, 0, -recoveryBaselineDays), d),
+			RestingHeart:     valueOrNaN(rhr, date),
+			RestingBaseline:  getDailyAverage(db, "RestingHeartRecords", "heartRate", d.AddDate(0, 0, -recoveryBaselineDays), d),
+			SpO2:             valueOrNaN(spo2, date),
+			MinutesAsleep:    valueOrNaN(asleep, date),
+			SleepEfficiency:  valueOrNaN(efficiency, date),
+			BreathingRate:    valueOrNaN(br, date),
+			SkinTempRelative: valueOrNaN(temp, date),
+		})
+	}
+	return days
+}
+
+func valueOrNaN(values map[string]float64, date string) float64 {
+	if v, ok := values[date]; ok {
+		return v
+	}
+	return math.NaN()
+}
+
+// getDailyValues returns column of a table keyed by date for start to end inclusive.
+func getDailyValues(db *sql.DB, table, column string, start, end time.Time) map[string]float64 {
+	rows, err := db.Query(
+		fmt.Sprintf(`SELECT date(date), %s FROM %s WHERE date(date) >= ? AND date(date) <= ?`, column, table),
+		start.Format("2006-01-02"), end.Format("2006-01-02"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]float64)
+	for rows.Next() {
+		var date string
+		var v sql.NullFloat64
+		if err := rows.Scan(&date, &v); err != nil {
+			log.Fatal(err)
+		}
+		if v.Valid {
+			values[date] = v.Float64
+		}
+	}
+	return values
+}
+
+// getDailyAverage returns the average of column for the days from start
+// up to but not including end, or 0 if there are no values.
+func getDailyAverage(db *sql.DB, table, column string, start, end time.Time) float64 {
+	var avg sql.NullFloat64
+	err := db.QueryRow(
+		fmt.Sprintf(`SELECT avg(%s) FROM %s WHERE date(date) >= ? AND date(date) < ?`, column, table),
+		start.Format("2006-01-02"), end.Format("2006-01-02"),
+	).Scan(&avg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return avg.Float64
+}
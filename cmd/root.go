@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/haclark30/vitus/fitbit"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "vitus",
 	Short: "my cli for things in my life",
+	// the fitbit client asks for a login when there's no token yet, so
+	// it's made once a command runs rather than on import
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		client = fitbit.NewFitbitClient()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("test")
 	},
@@ -20,6 +26,7 @@ func init() {
 	rootCmd.AddCommand(ynabCmd)
 	rootCmd.AddCommand(createDbCmd)
 	rootCmd.AddCommand(teaCmd)
+	rootCmd.AddCommand(syncCmd)
 }
 
 func Execute() {
@@ -0,0 +1,472 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/haclark30/vitus/daterange"
+	"github.com/haclark30/vitus/db"
+	"github.com/haclark30/vitus/fitbit"
+	"github.com/haclark30/vitus/habitica"
+	"github.com/spf13/cobra"
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "score habitica tasks from fitbit data",
+	Long: `Rules score habitica tasks from the day's fitbit data. They are read from
+a JSON file given by --rules or HABITICA_RULES, by default habitica-rules.json:
+
+	[
+	  {"task": "Walk 10k", "metric": "steps", "op": ">=", "value": "goal"},
+	  {"task": "Drink water", "metric": "water", "per": 16},
+	  {"task": "Sleep 7h", "metric": "sleep", "op": ">=", "value": 420}
+	]
+
+Metrics are steps, water (fl oz), sleep (main sleep minutes) and azm (active
+zone minutes). A rule with op and value scores its task once when the metric
+meets the value, which may be "goal" for the fitbit goal of steps or azm. A
+rule with per scores its task once for every per of the metric. Direction
+may be set to "down". Rules run for every day a sync covers, so a goal met
+late is still scored when the sync runs after midnight, and the scores are
+recorded so a task is never scored twice for the same day.`,
+}
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list rules",
+	Run:   rulesList,
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "evaluate rules against a day's data without scoring",
+	Run:   rulesTest,
+}
+
+var rulesRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "score the tasks of rules met on each day, by default today",
+	Run:   rulesRun,
+}
+
+var (
+	rulesFile   string
+	rulesDate   string
+	rulesFrom   string
+	rulesTo     string
+	rulesDryRun bool
+)
+
+func init() {
+	defaultRules := os.Getenv("HABITICA_RULES")
+	if defaultRules == "" {
+		defaultRules = "habitica-rules.json"
+	}
+	rulesCmd.PersistentFlags().StringVar(&rulesFile, "rules", defaultRules, "JSON file of rules")
+	rulesTestCmd.Flags().StringVar(&rulesDate, "date", "today", "day to evaluate")
+	addDateRangeFlags(rulesRunCmd, &rulesFrom, &rulesTo)
+	rulesRunCmd.Flags().BoolVar(&rulesDryRun, "dry-run", false, "show what would be scored")
+	rulesCmd.AddCommand(rulesListCmd)
+	rulesCmd.AddCommand(rulesTestCmd)
+	rulesCmd.AddCommand(rulesRunCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
+
+// ruleValue is a rule's threshold, either a number or the fitbit goal.
+// set tells a value of 0 from one left out.
+type ruleValue struct {
+	value  float64
+	isGoal bool
+	set    bool
+}
+
+func (v *ruleValue) UnmarshalJSON(b []byte) error {
+	v.set = true
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s != "goal" {
+			return fmt.Errorf("value must be a number or \"goal\", not %q", s)
+		}
+		v.isGoal = true
+		return nil
+	}
+	return json.Unmarshal(b, &v.value)
+}
+
+func (v ruleValue) String() string {
+	if v.isGoal {
+		return "goal"
+	}
+	return fmt.Sprintf("%g", v.value)
+}
+
+type habiticaRule struct {
+	Name      string    `json:"name"`
+	Task      string    `json:"task"`
+	Metric    string    `json:"metric"`
+	Op        string    `json:"op"`
+	Value     ruleValue `json:"value"`
+	Per       float64   `json:"per"`
+	Direction string    `json:"direction"`
+}
+
+var ruleMetrics = []string{"steps", "water", "sleep", "azm"}
+
+// key identifies the rule in HabiticaRuleScores.
+func (r habiticaRule) key() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Task + ":" + r.Metric
+}
+
+func (r habiticaRule) condition() string {
+	if r.Per > 0 {
+		return fmt.Sprintf("per %g %s", r.Per, r.Metric)
+	}
+	return fmt.Sprintf("%s %s %s", r.Metric, r.Op, r.Value)
+}
+
+func (r habiticaRule) up() bool {
+	return r.Direction != "down"
+}
+
+func (r habiticaRule) validate() error {
+	if r.Task == "" {
+		return fmt.Errorf("rule %s has no task", r.key())
+	}
+	found := false
+	for _, m := range ruleMetrics {
+		found = found || m == r.Metric
+	}
+	if !found {
+		return fmt.Errorf("rule %s: metric must be one of %s", r.key(), strings.Join(ruleMetrics, ", "))
+	}
+	if r.Value.isGoal && r.Metric != "steps" && r.Metric != "azm" {
+		return fmt.Errorf("rule %s: only steps and azm have a goal", r.key())
+	}
+	if r.Direction != "" && r.Direction != "up" && r.Direction != "down" {
+		return fmt.Errorf("rule %s: direction must be up or down", r.key())
+	}
+	if r.Per <= 0 {
+		switch r.Op {
+		case ">=", ">", "<=", "<", "==":
+		default:
+			return fmt.Errorf("rule %s needs per, or op of >=, >, <=, < or ==", r.key())
+		}
+		// a missing value would compare against 0 and score every day
+		if !r.Value.set {
+			return fmt.Errorf("rule %s needs per, or a value or \"goal\" for %s", r.key(), r.Op)
+		}
+	}
+	return nil
+}
+
+// times returns how many times the rule's task should be scored for
+// a day with value of the metric.
+func (r habiticaRule) times(value, goal float64) int {
+	if r.Per > 0 {
+		return int(value / r.Per)
+	}
+	threshold := r.Value.value
+	if r.Value.isGoal {
+		threshold = goal
+	}
+	var met bool
+	switch r.Op {
+	case ">=":
+		met = value >= threshold
+	case ">":
+		met = value > threshold
+	case "<=":
+		met = value <= threshold
+	case "<":
+		met = value < threshold
+	case "==":
+		met = value == threshold
+	}
+	if met {
+		return 1
+	}
+	return 0
+}
+
+func loadHabiticaRules(path string) ([]habiticaRule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []habiticaRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for i := range rules {
+		if rules[i].Op == "" {
+			rules[i].Op = ">="
+		}
+		if err := rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return rules, nil
+}
+
+// ruleResult is a rule evaluated for a day.
+type ruleResult struct {
+	rule   habiticaRule
+	value  float64 // NaN when there is no data for the day
+	goal   float64
+	want   int
+	scored int
+}
+
+// getRuleMetrics returns the value of each metric for day from the db,
+// leaving out metrics with no data.
+func getRuleMetrics(db *sql.DB, day time.Time) (map[string]float64, error) {
+	date := day.Format("2006-01-02")
+	metrics := make(map[string]float64)
+	queries := map[string]string{
+		"steps": `SELECT steps FROM DailyStepsRecords WHERE date = ?`,
+		"water": `SELECT ounces FROM WaterRecords WHERE date = ?`,
+		"sleep": `SELECT minutesAsleep FROM SleepRecords WHERE date = ?`,
+		"azm": `SELECT sum(total) FROM ActiveZoneMinutesRecords
+			WHERE date(time, 'unixepoch', 'localtime') = ?`,
+	}
+	for metric, query := range queries {
+		var v sql.NullFloat64
+		err := db.QueryRow(query, date).Scan(&v)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if v.Valid {
+			metrics[metric] = v.Float64
+		}
+	}
+	return metrics, nil
+}
+
+func getRuleScores(db *sql.DB, day time.Time) (map[string]int, error) {
+	rows, err := db.Query(`SELECT rule, times FROM HabiticaRuleScores WHERE date = ?`, day.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	scores := make(map[string]int)
+	for rows.Next() {
+		var rule string
+		var times int
+		if err := rows.Scan(&rule, &times); err != nil {
+			return nil, err
+		}
+		scores[rule] = times
+	}
+	return scores, rows.Err()
+}
+
+// getRuleGoals returns the steps and azm goals of day, from the goals last
+// synced on or before it. ok is false when no goals have been synced.
+func getRuleGoals(db *sql.DB, day time.Time) (steps, azm float64, ok bool, err error) {
+	err = db.QueryRow(
+		`SELECT steps, activeZoneMinutes FROM DailyGoals WHERE date <= ? ORDER BY date DESC LIMIT 1`,
+		day.Format("2006-01-02")).Scan(&steps, &azm)
+	if err == sql.ErrNoRows {
+		return 0, 0, false, nil
+	}
+	return steps, azm, err == nil, err
+}
+
+func recordRuleScore(db *sql.DB, rule habiticaRule, day time.Time, times int) error {
+	_, err := db.Exec(
+		`INSERT INTO HabiticaRuleScores (rule, date, times) VALUES (?, ?, ?)
+		ON CONFLICT(rule, date) DO UPDATE SET times = excluded.times`,
+		rule.key(), day.Format("2006-01-02"), times)
+	return err
+}
+
+// evaluateRules evaluates rules against day's data. Goals are read from
+// the db, and only fetched from fitbit when none have been synced yet.
+func evaluateRules(db *sql.DB, fitbitClient *http.Client, rules []habiticaRule, day time.Time) ([]ruleResult, error) {
+	metrics, err := getRuleMetrics(db, day)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := getRuleScores(db, day)
+	if err != nil {
+		return nil, err
+	}
+
+	needGoals := false
+	for _, r := range rules {
+		needGoals = needGoals || r.Value.isGoal
+	}
+	var stepsGoal, azmGoal float64
+	if needGoals {
+		var ok bool
+		if stepsGoal, azmGoal, ok, err = getRuleGoals(db, day); err != nil {
+			return nil, err
+		}
+		if !ok {
+			goals, err := fitbit.GetDailyGoals(fitbitClient)
+			if err != nil {
+				return nil, err
+			}
+			stepsGoal, azmGoal = float64(goals.Steps), float64(goals.ActiveZoneMinutes)
+		}
+	}
+
+	var results []ruleResult
+	for _, r := range rules {
+		result := ruleResult{rule: r, value: math.NaN(), scored: scores[r.key()]}
+		if r.Value.isGoal {
+			result.goal = stepsGoal
+			if r.Metric == "azm" {
+				result.goal = azmGoal
+			}
+		}
+		if v, ok := metrics[r.Metric]; ok {
+			result.value = v
+			result.want = r.times(v, result.goal)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runHabiticaRules scores the tasks of rules met on each day of dates
+// that haven't been scored for that day yet, writing what it does to out.
+func runHabiticaRules(db *sql.DB, rules []habiticaRule, dates daterange.Range, dryRun bool, out io.Writer) error {
+	habClient, err := habiticaClient()
+	if err != nil {
+		return err
+	}
+	var tasks []habitica.Task
+	for day := truncateDay(dates.Start); !day.After(dates.End); day = day.AddDate(0, 0, 1) {
+		results, err := evaluateRules(db, client, rules, day)
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			pending := res.want - res.scored
+			if pending <= 0 {
+				continue
+			}
+			if tasks == nil {
+				if tasks, err = habitica.GetTasks(habClient, ""); err != nil {
+					return err
+				}
+			}
+			task, err := findTask(tasks, res.rule.Task)
+			if err != nil {
+				return fmt.Errorf("rule %s: %w", res.rule.key(), err)
+			}
+			if dryRun {
+				fmt.Fprintf(out, "would score %s %s %d times for %s (%s, %g)\n", task.Text,
+					directionName(res.rule.up()), pending, day.Format("2006-01-02"), res.rule.condition(), res.value)
+				continue
+			}
+			for i := 0; i < pending; i++ {
+				if _, err := habitica.ScoreTask(habClient, task.Id, res.rule.up()); err != nil {
+					return err
+				}
+				if err := recordRuleScore(db, res.rule, day, res.scored+i+1); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(out, "scored %s %s %d times for %s (%s, %g)\n", task.Text,
+				directionName(res.rule.up()), pending, day.Format("2006-01-02"), res.rule.condition(), res.value)
+		}
+	}
+	return nil
+}
+
+func directionName(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
+
+func mustLoadRules() []habiticaRule {
+	rules, err := loadHabiticaRules(rulesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rules
+}
+
+func rulesList(cmd *cobra.Command, args []string) {
+	w := newTabWriter()
+	fmt.Fprintln(w, "NAME\tTASK\tCONDITION\tDIRECTION")
+	for _, r := range mustLoadRules() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.key(), r.Task, r.condition(), directionName(r.up()))
+	}
+	w.Flush()
+}
+
+func rulesTest(cmd *cobra.Command, args []string) {
+	rules := mustLoadRules()
+	day := parseDateRange(rulesDate, rulesDate, "", time.Time{}).Start
+	db := db.GetDb()
+
+	tasks, err := habitica.GetTasks(newHabiticaClient(), "")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results, err := evaluateRules(db, client, rules, day)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := newTabWriter()
+	fmt.Fprintln(w, "NAME\tTASK\tCONDITION\tVALUE\tSCORES\tSCORED")
+	for _, res := range results {
+		taskName := res.rule.Task
+		if task, err := findTask(tasks, res.rule.Task); err != nil {
+			taskName += " (" + err.Error() + ")"
+		} else {
+			taskName = task.Text
+		}
+		condition := res.rule.condition()
+		if res.rule.Value.isGoal {
+			condition += fmt.Sprintf(" (%g)", res.goal)
+		}
+		value := "no data"
+		if !math.IsNaN(res.value) {
+			value = fmt.Sprintf("%g", res.value)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", res.rule.key(), taskName, condition, value, res.want, res.scored)
+	}
+	w.Flush()
+}
+
+func rulesRun(cmd *cobra.Command, args []string) {
+	dates := parseDateRange(rulesFrom, rulesTo, "today", time.Time{})
+	if err := runHabiticaRules(db.GetDb(), mustLoadRules(), dates, rulesDryRun, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// syncHabiticaRules runs the rules for the days a sync covered, if there
+// is a rules file.
+func syncHabiticaRules(db *sql.DB, dates daterange.Range, out io.Writer) error {
+	rules, err := loadHabiticaRules(rulesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return runHabiticaRules(db, rules, dates, false, out)
+}
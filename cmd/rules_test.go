@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haclark30/vitus/db"
+)
+
+// testDb opens an empty db in a temporary directory.
+func testDb(t *testing.T) *sql.DB {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	database := db.GetDb()
+	t.Cleanup(func() {
+		database.Close()
+		os.Chdir(wd)
+	})
+	return database
+}
+
+func TestRuleValueUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want ruleValue
+	}{
+		{`{"value": 420}`, ruleValue{value: 420, set: true}},
+		{`{"value": 0}`, ruleValue{set: true}},
+		{`{"value": 2.5}`, ruleValue{value: 2.5, set: true}},
+		{`{"value": "goal"}`, ruleValue{isGoal: true, set: true}},
+		{`{}`, ruleValue{}},
+	}
+	for _, tt := range tests {
+		var r habiticaRule
+		if err := json.Unmarshal([]byte(tt.json), &r); err != nil {
+			t.Errorf("unmarshal %s error: %v", tt.json, err)
+			continue
+		}
+		if r.Value != tt.want {
+			t.Errorf("unmarshal %s = %+v, want %+v", tt.json, r.Value, tt.want)
+		}
+	}
+	for _, s := range []string{`{"value": "420"}`, `{"value": "Goal"}`, `{"value": true}`} {
+		var r habiticaRule
+		if err := json.Unmarshal([]byte(s), &r); err == nil {
+			t.Errorf("unmarshal %s = %+v, want an error", s, r.Value)
+		}
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	ten := ruleValue{value: 10, set: true}
+	goal := ruleValue{isGoal: true, set: true}
+	tests := []struct {
+		rule habiticaRule
+		err  string // empty when valid
+	}{
+		{habiticaRule{Task: "walk", Metric: "steps", Op: ">=", Value: goal}, ""},
+		{habiticaRule{Task: "walk", Metric: "azm", Op: ">", Value: goal}, ""},
+		{habiticaRule{Task: "water", Metric: "water", Per: 16}, ""},
+		{habiticaRule{Task: "sleep", Metric: "sleep", Op: "<", Value: ten, Direction: "down"}, ""},
+		{habiticaRule{Task: "sleep", Metric: "sleep", Op: "==", Value: ruleValue{set: true}, Direction: "up"}, ""},
+		{habiticaRule{Metric: "steps", Op: ">=", Value: ten}, "has no task"},
+		{habiticaRule{Task: "walk", Metric: "floors", Op: ">=", Value: ten}, "metric must be one of"},
+		{habiticaRule{Task: "water", Metric: "water", Op: ">=", Value: goal}, "only steps and azm have a goal"},
+		{habiticaRule{Task: "walk", Metric: "steps", Op: ">=", Value: ten, Direction: "sideways"}, "direction"},
+		{habiticaRule{Task: "walk", Metric: "steps", Op: "=>", Value: ten}, "needs per, or op"},
+		{habiticaRule{Task: "walk", Metric: "steps"}, "needs per, or op"},
+		{habiticaRule{Task: "walk", Metric: "steps", Op: ">="}, "needs per, or a value"},
+	}
+	for _, tt := range tests {
+		err := tt.rule.validate()
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%+v.validate() error: %v", tt.rule, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("%+v.validate() = nil, want an error with %q", tt.rule, tt.err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%+v.validate() = %v, want an error with %q", tt.rule, err, tt.err)
+		}
+	}
+}
+
+func TestRuleTimes(t *testing.T) {
+	ten := ruleValue{value: 10, set: true}
+	tests := []struct {
+		rule        habiticaRule
+		value, goal float64
+		want        int
+	}{
+		{habiticaRule{Op: ">=", Value: ten}, 10, 0, 1},
+		{habiticaRule{Op: ">=", Value: ten}, 9.9, 0, 0},
+		{habiticaRule{Op: ">", Value: ten}, 10, 0, 0},
+		{habiticaRule{Op: ">", Value: ten}, 11, 0, 1},
+		{habiticaRule{Op: "<=", Value: ten}, 10, 0, 1},
+		{habiticaRule{Op: "<=", Value: ten}, 11, 0, 0},
+		{habiticaRule{Op: "<", Value: ten}, 10, 0, 0},
+		{habiticaRule{Op: "<", Value: ten}, 0, 0, 1},
+		{habiticaRule{Op: "==", Value: ten}, 10, 0, 1},
+		{habiticaRule{Op: "==", Value: ten}, 10.5, 0, 0},
+
+		// the goal replaces the value
+		{habiticaRule{Op: ">=", Value: ruleValue{isGoal: true, set: true}}, 10000, 10000, 1},
+		{habiticaRule{Op: ">=", Value: ruleValue{isGoal: true, set: true}}, 9999, 10000, 0},
+
+		// per scores once for every whole per
+		{habiticaRule{Per: 16}, 0, 0, 0},
+		{habiticaRule{Per: 16}, 15.9, 0, 0},
+		{habiticaRule{Per: 16}, 16, 0, 1},
+		{habiticaRule{Per: 16}, 70, 0, 4},
+		{habiticaRule{Per: 16, Op: ">=", Value: ruleValue{value: 1000, set: true}}, 32, 0, 2},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.times(tt.value, tt.goal); got != tt.want {
+			t.Errorf("%s times(%g, %g) = %d, want %d", tt.rule.condition(), tt.value, tt.goal, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	db := testDb(t)
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)
+	for _, q := range []string{
+		`INSERT INTO DailyStepsRecords (date, steps) VALUES ('2024-03-15', 12000)`,
+		`INSERT INTO DailyGoals (date, steps, activeZoneMinutes) VALUES ('2024-03-01', 10000, 22)`,
+		`INSERT INTO DailyGoals (date, steps, activeZoneMinutes) VALUES ('2024-03-20', 15000, 30)`,
+		`INSERT INTO HabiticaRuleScores (rule, date, times) VALUES ('walk:steps', '2024-03-15', 1)`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules := []habiticaRule{
+		{Task: "walk", Metric: "steps", Op: ">=", Value: ruleValue{isGoal: true, set: true}},
+		{Task: "water", Metric: "water", Per: 16},
+	}
+	// the goals come from the db, so the fitbit client is never used
+	results, err := evaluateRules(db, nil, rules, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("evaluateRules returned %d results, want 2", len(results))
+	}
+	if steps := results[0]; steps.value != 12000 || steps.goal != 10000 || steps.want != 1 || steps.scored != 1 {
+		t.Errorf("steps result = %+v, want value 12000, goal 10000, want 1, scored 1", steps)
+	}
+	// a day without the metric scores nothing
+	if water := results[1]; !math.IsNaN(water.value) || water.want != 0 || water.scored != 0 {
+		t.Errorf("water result = %+v, want no value and nothing to score", water)
+	}
+}
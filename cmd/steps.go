@@ -4,160 +4,465 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/NimbleMarkets/ntcharts/barchart"
+	"github.com/NimbleMarkets/ntcharts/canvas"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type StepsChart struct {
 	barchart.Model
-	db           *sql.DB
-	stepsData    []barchart.BarData
-	activeIdx    int
-	startDayDiff int
-	endDayDiff   int
+	db        *sql.DB
+	stepsData []barchart.BarData
+	bars      []stepsBar
+	activeIdx int
+	spinner   loadingSpinner
+	compact   bool
+	date      viewDate
+	goal      float64
+	compare   bool
+	profile   []float64
+	tooltip   tooltip
+}
+
+// stepsBar is the steps of an hour, day or month. ok is false when
+// nothing was recorded for it, e.g. an hour not synced yet, which is
+// shown apart from an hour with no steps.
+type stepsBar struct {
+	label string
+	steps float64
+	ok    bool
+}
+
+// stepsDataMsg carries the steps loaded for date, per hour when zoomed
+// to a day, with the average steps of each hour when comparing.
+type stepsDataMsg struct {
+	date    viewDate
+	compare bool
+	bars    []stepsBar
+	profile []float64
+}
+
+// load queries the chart's range in a tea.Cmd.
+func (s StepsChart) load() tea.Cmd {
+	db, date, compare := s.db, s.date, s.compare
+	return func() tea.Msg {
+		if date.zoom == zoomDay {
+			msg := stepsDataMsg{date: date, compare: compare, bars: GetStepsData(db, date.day)}
+			if compare {
+				msg.profile = GetStepsProfile(db, date.day, compareDays)
+			}
+			return msg
+		}
+		return stepsDataMsg{date: date, compare: compare, bars: GetStepsTotals(db, date)}
+	}
+}
+
+// SetDate shows the steps of date's range.
+func (s *StepsChart) SetDate(date viewDate) tea.Cmd {
+	s.date = date
+	return tea.Batch(s.spinner.start(), s.load())
+}
+
+// SetGoal sets the daily steps goal the day's cumulative steps are
+// drawn against, 0 when there is none.
+func (s *StepsChart) SetGoal(goal float64) {
+	s.goal = goal
+	s.Draw()
 }
 
 func (s StepsChart) Update(msg tea.Msg) (StepsChart, tea.Cmd) {
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "down", "j":
-			// TODO: handle index out of range
-			if s.Canvas.Focused() {
-				s.Clear()
-				s.startDayDiff--
-				s.endDayDiff--
-				s.stepsData = GetStepsData(s.db, s.startDayDiff, s.endDayDiff)
-				s.PushAll(s.stepsData)
+	case stepsDataMsg:
+		if !msg.date.Equal(s.date) || msg.compare != s.compare {
+			// a range that has been navigated away from
+			return s, nil
+		}
+		s.spinner.stop()
+		// a reload of the same range, e.g. after a sync, keeps the selection
+		keep := len(msg.bars) == len(s.bars) && s.activeIdx < len(msg.bars)
+		s.bars = msg.bars
+		s.profile = msg.profile
+		s.stepsData = make([]barchart.BarData, len(s.bars))
+		for i, bar := range s.bars {
+			s.stepsData[i] = barchart.BarData{
+				Label:  bar.label,
+				Values: []barchart.BarValue{{Name: "steps", Value: bar.steps, Style: stepsStyle}},
 			}
-		case "up", "k":
-			// TODO: handle index out of range
-			if s.Canvas.Focused() {
-				s.Clear()
-				s.startDayDiff++
-				s.endDayDiff++
-				s.stepsData = GetStepsData(s.db, s.startDayDiff, s.endDayDiff)
-				s.PushAll(s.stepsData)
+		}
+		if !keep {
+			s.activeIdx = s.defaultBar()
+		}
+		if len(s.stepsData) > 0 {
+			s.stepsData[s.activeIdx].Values[0].Style = stepsStyle.Faint(true)
+		}
+		s.Clear()
+		s.PushAll(s.stepsData)
+		// the average bars share the scale of the day's
+		for _, avg := range s.profile {
+			if avg > s.MaxValue() {
+				s.SetMax(avg)
 			}
-		case "right", "l":
+		}
+		s.Draw()
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		s.spinner, cmd = s.spinner.update(msg)
+		return s, cmd
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, teaKeys.Compare):
+			s.compare = !s.compare
+			return s, tea.Batch(s.spinner.start(), s.load())
+		case key.Matches(msg, teaKeys.Right):
 			// go forward through bars
-			if s.Canvas.Focused() {
-				s.stepsData[s.activeIdx].Values[0].Style = s.stepsData[s.activeIdx].Values[0].Style.Faint(false)
-				if s.activeIdx < len(s.stepsData)-1 {
-					s.activeIdx++
-				} else {
-					s.activeIdx = 0
-				}
-				s.stepsData[s.activeIdx].Values[0].Style = s.stepsData[s.activeIdx].Values[0].Style.Faint(true)
-				s.Draw()
+			if s.Canvas.Focused() && len(s.stepsData) > 0 {
+				s.selectBar((s.activeIdx + 1) % len(s.stepsData))
 			}
-		case "left", "h":
+		case key.Matches(msg, teaKeys.Left):
 			// go backward through bars
-			if s.Canvas.Focused() {
-				s.stepsData[s.activeIdx].Values[0].Style = s.stepsData[s.activeIdx].Values[0].Style.Faint(false)
-				if s.activeIdx > 0 {
-					s.activeIdx--
-				} else {
-					s.activeIdx = len(s.stepsData) - 1
-				}
-				s.stepsData[s.activeIdx].Values[0].Style = s.stepsData[s.activeIdx].Values[0].Style.Faint(true)
-				s.Draw()
+			if s.Canvas.Focused() && len(s.stepsData) > 0 {
+				s.selectBar((s.activeIdx - 1 + len(s.stepsData)) % len(s.stepsData))
 			}
 		}
+	case tea.MouseMsg:
+		s.tooltip = tooltip{}
+		idx, x, ok := hoverBar(&s.Model, msg, len(s.bars))
+		if !ok {
+			return s, nil
+		}
+		text := s.bars[idx].label + "  " + s.bars[idx].String()
+		if idx < len(s.profile) {
+			text += fmt.Sprintf("  avg %d", int(s.profile[idx]))
+		}
+		s.tooltip = tooltip{x: x, text: text}
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			s.selectBar(idx)
+		}
 	}
 	return s, nil
 }
 
+// defaultBar returns the bar selected when a range is loaded: the
+// current hour of today, or else the last bar with data.
+func (s StepsChart) defaultBar() int {
+	if s.date.zoom == zoomDay && s.date.day.Equal(truncateDay(time.Now())) {
+		return time.Now().Hour()
+	}
+	for i := len(s.bars) - 1; i >= 0; i-- {
+		if s.bars[i].ok {
+			return i
+		}
+	}
+	return 0
+}
+
+func (b stepsBar) String() string {
+	if !b.ok {
+		return "no data"
+	}
+	return fmt.Sprintf("%d steps", int(b.steps))
+}
+
+// selectBar highlights the bar at idx in place of the selected one.
+func (s *StepsChart) selectBar(idx int) {
+	s.stepsData[s.activeIdx].Values[0].Style = stepsStyle
+	s.activeIdx = idx
+	s.stepsData[s.activeIdx].Values[0].Style = stepsStyle.Faint(true)
+	s.Draw()
+}
+
+// Draw draws the bars with the average of each behind them, then marks
+// the bars with no data and, for a day, the cumulative steps against the
+// goal.
+func (s *StepsChart) Draw() {
+	s.Model.Draw()
+	graphHeight := s.Model.Height() - 2 // above the axis and labels
+	if graphHeight < 1 {
+		return
+	}
+	step := s.BarWidth() + s.BarGap()
+	// fill sets the empty cells of row y in bar i's columns to c
+	fill := func(i, y int, c canvas.Cell) {
+		for x := i * step; x < i*step+s.BarWidth(); x++ {
+			p := canvas.Point{X: x, Y: y}
+			if r := s.Canvas.Cell(p).Rune; r == 0 || r == ' ' {
+				s.Canvas.SetCell(p, c)
+			}
+		}
+	}
+	for i, avg := range s.profile {
+		rows := min(int(math.Round(avg*s.Scale())), graphHeight)
+		for y := graphHeight - rows; y < graphHeight; y++ {
+			fill(i, y, canvas.Cell{Rune: '▒', Style: stepsProfileStyle})
+		}
+	}
+	for i, bar := range s.bars {
+		if bar.ok {
+			continue
+		}
+		for y := 0; y < graphHeight; y++ {
+			fill(i, y, canvas.Cell{Rune: '░', Style: stepsMissingStyle})
+		}
+	}
+	if s.date.zoom != zoomDay {
+		return
+	}
+
+	// the cumulative steps and goal share a scale of the larger of them
+	total := s.total()
+	top := max(total, s.goal)
+	if top == 0 {
+		return
+	}
+	row := func(v float64) int {
+		return graphHeight - 1 - int(math.Round(v/top*float64(graphHeight-1)))
+	}
+	if s.goal > 0 {
+		y := row(s.goal)
+		for x := 0; x < s.Model.Width(); x++ {
+			p := canvas.Point{X: x, Y: y}
+			if r := s.Canvas.Cell(p).Rune; r == 0 || r == ' ' {
+				s.Canvas.SetCell(p, canvas.Cell{Rune: '┄', Style: stepsGoalStyle})
+			}
+		}
+	}
+	var cumulative float64
+	for i, bar := range s.bars {
+		if !bar.ok {
+			continue
+		}
+		cumulative += bar.steps
+		p := canvas.Point{X: i*step + s.BarWidth()/2, Y: row(cumulative)}
+		style := stepsCumulativeStyle
+		if r := s.Canvas.Cell(p).Rune; r != 0 && r != ' ' {
+			// keep the color of the bar under the point
+			style = style.Copy().Background(s.Canvas.Cell(p).Style.GetForeground())
+		}
+		s.Canvas.SetCell(p, canvas.Cell{Rune: '•', Style: style})
+	}
+}
+
+// total returns the steps of every bar with data.
+func (s StepsChart) total() float64 {
+	var total float64
+	for _, bar := range s.bars {
+		if bar.ok {
+			total += bar.steps
+		}
+	}
+	return total
+}
+
+// title names what each bar totals at the chart's zoom.
+func (s StepsChart) title() string {
+	switch s.date.zoom {
+	case zoomDay:
+		return "steps per hour"
+	case zoomYear:
+		return "steps per month"
+	default:
+		return "steps per day"
+	}
+}
+
 func (s StepsChart) View() string {
-	currentDay := time.Now().Add(time.Duration(24*s.startDayDiff) * time.Hour)
-	currentDayStr := currentDay.Format("2006-01-02")
+	currentDayStr := s.date.String()
 
-	currentHourSteps := s.stepsData[s.activeIdx].Values[0].Value
+	// the steps of the selected bar and the range
+	var bar stepsBar
+	if len(s.bars) > 0 {
+		bar = s.bars[s.activeIdx]
+	}
+	total := fmt.Sprintf("%d steps", int(s.total()))
+	if s.date.zoom == zoomDay && s.goal > 0 {
+		total += fmt.Sprintf("\n%d%% of goal", int(s.total()/s.goal*100))
+	}
+	if s.compact {
+		return lipgloss.JoinVertical(lipgloss.Center,
+			s.spinner.title(s.title()),
+			fmt.Sprintf("%s  %s: %s  %s: %d", currentDayStr, bar.label, bar, s.date.zoom, int(s.total())),
+			s.Model.View(),
+			s.tooltip.View(s.Model.Width()),
+		)
+	}
+	barText := bar.String()
+	if s.activeIdx < len(s.profile) {
+		barText += fmt.Sprintf("\navg %d", int(s.profile[s.activeIdx]))
+	}
+	side := strings.Join([]string{
+		bar.label, barText, "",
+		s.date.zoom.String(), total,
+	}, "\n")
+	if s.date.zoom == zoomDay {
+		side += "\n\n" + stepsCumulativeStyle.Render("•") + " so far"
+		if s.goal > 0 {
+			side += "\n" + stepsGoalStyle.Render("┄") + " goal"
+		}
+		if len(s.profile) > 0 {
+			side += "\n" + stepsProfileStyle.Render("▒") + fmt.Sprintf(" %d-day avg", compareDays)
+		}
+	}
 	return lipgloss.JoinHorizontal(lipgloss.Center,
-		lipgloss.NewStyle().Align(lipgloss.Center).Render("steps per hour\n"+currentDayStr+"\n"+s.Model.View()),
-		lipgloss.NewStyle().Width(10).Render(fmt.Sprintf("%d steps", int(currentHourSteps))),
+		lipgloss.NewStyle().Align(lipgloss.Center).Render(s.spinner.title(s.title())+"\n"+currentDayStr+"\n"+s.Model.View()+"\n"+s.tooltip.View(s.Model.Width())),
+		lipgloss.NewStyle().Width(stepsSideWidth).PaddingLeft(1).Render(side),
 	)
 }
 
+const stepsSideWidth = 14
+
+// SetSize fits the chart, its title and tooltip into width by height,
+// moving the bar's steps under the title when there is no room beside
+// the chart.
+func (s *StepsChart) SetSize(width, height int) {
+	s.compact = width < compactPanelWidth
+	chartWidth := width
+	if !s.compact {
+		chartWidth -= stepsSideWidth
+	}
+	// the title and date above the chart, the tooltip below
+	s.Resize(max(chartWidth, 1), max(height-3, 1))
+	s.Draw()
+}
+
+// NewStepsChart returns an empty chart for today, its data is loaded by Init.
 func NewStepsChart(db *sql.DB, width, height int) StepsChart {
-	stepsData := GetStepsData(db, 0, 1)
-	barChart := barchart.New(width, height-1, barchart.WithDataSet(stepsData))
+	return StepsChart{
+		Model:   barchart.New(width, height-1),
+		db:      db,
+		spinner: newLoadingSpinner(),
+		date:    todayViewDate(zoomDay),
+	}
+}
 
-	var activeIdx int
-	for idx, stepCol := range stepsData {
-		if stepCol.Values[0].Value > 0 {
-			stepCol.Values[0].Style = stepCol.Values[0].Style.Faint(true)
-			activeIdx = idx
-			break
+// Init starts loading the chart's day.
+func (s StepsChart) Init() tea.Cmd {
+	return tea.Batch(s.spinner.Tick, s.load())
+}
+
+// GetStepsTotals returns the steps of each day in date's range, or of each
+// month when zoomed to a year.
+func GetStepsTotals(db *sql.DB, date viewDate) []stepsBar {
+	bucket, label := "%Y-%m-%d", "02"
+	switch date.zoom {
+	case zoomWeek:
+		label = "Mon"
+	case zoomYear:
+		bucket, label = "%Y-%m", "Jan"
+	}
+	rows, err := db.Query(
+		`SELECT strftime(?, date) AS bucket, sum(steps) FROM DailyStepsRecords
+		WHERE date(date) >= ? AND date(date) < ?
+		GROUP BY bucket`,
+		bucket, date.Start().Format("2006-01-02"), date.End().Format("2006-01-02"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]float64)
+	for rows.Next() {
+		var b string
+		var steps float64
+		if err := rows.Scan(&b, &steps); err != nil {
+			log.Fatal(err)
 		}
+		totals[b] = steps
 	}
 
-	return StepsChart{
-		Model:        barChart,
-		db:           db,
-		stepsData:    stepsData,
-		activeIdx:    activeIdx,
-		startDayDiff: 0,
-		endDayDiff:   1,
+	// a bar for every day or month of the range, including those with no steps
+	var bars []stepsBar
+	for d := date.Start(); d.Before(date.End()); {
+		key, next := d.Format("2006-01-02"), d.AddDate(0, 0, 1)
+		if date.zoom == zoomYear {
+			key, next = d.Format("2006-01"), d.AddDate(0, 1, 0)
+		}
+		steps, ok := totals[key]
+		bars = append(bars, stepsBar{label: d.Format(label), steps: steps, ok: ok})
+		d = next
 	}
+	return bars
 }
-func GetStepsData(db *sql.DB, startDayDiff, endDayDiff int) []barchart.BarData {
 
-	stmt, err := db.Prepare(
+// GetStepsData returns the steps of each hour of day, from midnight.
+func GetStepsData(db *sql.DB, day time.Time) []stepsBar {
+	rows, err := db.Query(
 		`SELECT
-			strftime('%Y-%m-%d %H:00:00', datetime(time, 'unixepoch', 'localtime')) AS hour,
-			SUM(steps) AS step_sum FROM StepsRecords
-		WHERE hour >= date('now', ? || ' day') AND hour < date('now', ? || ' day')
-		GROUP BY hour;`,
+			cast(strftime('%H', time, 'unixepoch', 'localtime') AS INTEGER) AS hour,
+			sum(steps) FROM StepsRecords
+		WHERE date(time, 'unixepoch', 'localtime') = ?
+		GROUP BY hour`,
+		day.Format("2006-01-02"),
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer stmt.Close()
-	rows, err := stmt.Query(startDayDiff, endDayDiff)
+	defer rows.Close()
+
+	bars := make([]stepsBar, 24)
+	for hour := range bars {
+		bars[hour].label = fmt.Sprintf("%02d", hour)
+	}
+	for rows.Next() {
+		var hour int
+		var steps float64
+		if err := rows.Scan(&hour, &steps); err != nil {
+			log.Fatal(err)
+		}
+		if hour >= 0 && hour < len(bars) {
+			bars[hour].steps, bars[hour].ok = steps, true
+		}
+	}
+	return bars
+}
+
+// GetStepsProfile returns the average steps of each hour of the day over
+// the days with steps in the days before day, or nil if there are none.
+func GetStepsProfile(db *sql.DB, day time.Time, days int) []float64 {
+	start, end := day.AddDate(0, 0, -days).Format("2006-01-02"), day.Format("2006-01-02")
+	var daysWithData int
+	err := db.QueryRow(
+		`SELECT count(DISTINCT date(time, 'unixepoch', 'localtime')) FROM StepsRecords
+		WHERE date(time, 'unixepoch', 'localtime') >= ? AND date(time, 'unixepoch', 'localtime') < ?`,
+		start, end,
+	).Scan(&daysWithData)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if daysWithData == 0 {
+		return nil
+	}
+
+	rows, err := db.Query(
+		`SELECT
+			cast(strftime('%H', time, 'unixepoch', 'localtime') AS INTEGER) AS hour,
+			sum(steps) FROM StepsRecords
+		WHERE date(time, 'unixepoch', 'localtime') >= ? AND date(time, 'unixepoch', 'localtime') < ?
+		GROUP BY hour`,
+		start, end,
+	)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer rows.Close()
 
-	var steps []float64
-	var stepTimes []string
+	profile := make([]float64, 24)
 	for rows.Next() {
-		var step float64
-		var stepTime string
-		err = rows.Scan(&stepTime, &step)
-		steps = append(steps, step)
-		stepTimes = append(stepTimes, stepTime)
-		if err != nil {
+		var hour int
+		var steps float64
+		if err := rows.Scan(&hour, &steps); err != nil {
 			log.Fatal(err)
 		}
+		if hour >= 0 && hour < len(profile) {
+			profile[hour] = steps / float64(daysWithData)
+		}
 	}
-
-	var stepsData []barchart.BarData
-	for i := 0; i <= 23; i++ {
-		if i < len(stepTimes) {
-			stepTime, _ := time.Parse("2006-01-02 15:00:00", stepTimes[i])
-			stepsData = append(stepsData, barchart.BarData{
-				Label: stepTime.Format("15"),
-				Values: []barchart.BarValue{
-					{Name: "steps", Value: steps[i], Style: lipgloss.NewStyle().Foreground(lipgloss.Color("9"))},
-				},
-			})
-		} else {
-			// fill remaining hours with 0
-			// TODO: fix accessing steptimes when len == 0
-			stepTime, _ := time.Parse("2006-01-02 15:00:00", stepTimes[len(stepTimes)-1])
-			stepTime = stepTime.Add(time.Hour * time.Duration(i-len(stepTimes)+1))
-			stepsData = append(stepsData, barchart.BarData{
-				Label: stepTime.Format("15"),
-				Values: []barchart.BarValue{
-					{Name: "steps", Value: 0, Style: lipgloss.NewStyle().Foreground(lipgloss.Color("9"))},
-				},
-			})
-		}
-	}
-	return stepsData
+	return profile
 }
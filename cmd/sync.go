@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"database/sql"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/haclark30/vitus/daterange"
+	"github.com/haclark30/vitus/db"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "pull recent fitbit data and ynab changes into the db and run habitica rules",
+	Run:   syncRun,
+}
+
+var syncFrom string
+
+func init() {
+	syncCmd.Flags().StringVar(&syncFrom, "from", "-7d",
+		"first fitbit day to check, days already loaded are skipped")
+}
+
+func syncRun(cmd *cobra.Command, args []string) {
+	db := db.GetDb()
+	if err := runSync(db, syncFrom, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runSync loads any fitbit days since from that are missing or still
+// changing, scores habitica tasks from the rules and snapshots them,
+// then pulls the changes to every ynab budget. Scored tasks are
+// reported to out.
+func runSync(db *sql.DB, from string, out io.Writer) error {
+	dates, err := daterange.FromTo(from, "", time.Now())
+	if err != nil {
+		return err
+	}
+	if dates, err = dates.Bound(time.Time{}, time.Now()); err != nil {
+		return err
+	}
+	if err := loadFitbitDb(client, db, dates.Start, dates.End); err != nil {
+		return err
+	}
+	slog.Debug("synced fitbit", "from", dates.Start)
+
+	if os.Getenv("HABITICA_API_KEY") != "" {
+		if err := syncHabiticaRules(db, dates, out); err != nil {
+			return err
+		}
+		habClient, err := habiticaClient()
+		if err != nil {
+			return err
+		}
+		if err := snapshotHabitica(db, habClient); err != nil {
+			return err
+		}
+		slog.Debug("synced habitica")
+	}
+
+	if os.Getenv("YNAB_API_KEY") != "" {
+		if err := syncYnab(db, newYnabClient()); err != nil {
+			return err
+		}
+		slog.Debug("synced ynab")
+	}
+	return nil
+}
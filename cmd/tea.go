@@ -2,56 +2,131 @@ package cmd
 
 import (
 	"database/sql"
+	"errors"
+	"io/fs"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/haclark30/vitus/db"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
 )
 
 type activeState int
 
 const (
-	stepsActive activeState = iota
+	todayActive activeState = iota
+	stepsActive
 	weightActive
 	heartActive
+	activityActive
 	sleepActive
+	recoveryActive
+	budgetActive
+	habiticaActive
 	numStates // used to keep track of number of states
 )
 
 var teaCmd = &cobra.Command{
-	Use: "tea",
+	Use:   "tea",
+	Short: "browse the data in a terminal UI",
+	Long: `Tea browses the data in a terminal UI. Press ? for the keys of each tab.
+
+Keys may be remapped in a JSON file given by --keys or TEA_KEYS, by default
+tea-keys.json, mapping binding names to their keys:
+
+	{"quit": ["q", "ctrl+c"], "next_tab": ["tab", "n"], "refresh": []}
+
+An empty list disables a binding. The names are next_tab, prev_tab, help,
+quit, calendar, today, week_back, week_forward, zoom_out, zoom_in, left,
+right, up, down, chart_zoom_in, chart_zoom_out, select, refresh, score_up,
+score_down, checklist, compare, prev_month, next_month, prev_year,
+next_year and close.
+
+Colours come from the theme given by --theme or TEA_THEME: auto, which
+picks dark or light by the terminal's background, dark, light,
+high-contrast, colourblind or mono. Setting NO_COLOR always picks mono,
+which tells heart rate zones and data sets apart by their runes.`,
 	Run: runTea,
 }
 
-var defaultStyle = lipgloss.NewStyle().
-	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("63")).
-	Align(lipgloss.Center)
+var (
+	teaSyncEvery time.Duration
+	teaKeysFile  string
+	teaThemeName string
+)
+
+func init() {
+	teaCmd.Flags().DurationVar(&teaSyncEvery, "sync-every", 15*time.Minute,
+		"how often to sync the last day of data in the background, 0 to never sync")
+	defaultKeys := os.Getenv("TEA_KEYS")
+	if defaultKeys == "" {
+		defaultKeys = "tea-keys.json"
+	}
+	teaCmd.Flags().StringVar(&teaKeysFile, "keys", defaultKeys, "JSON file of key bindings")
+	defaultTheme := os.Getenv("TEA_THEME")
+	if defaultTheme == "" {
+		defaultTheme = "auto"
+	}
+	teaCmd.Flags().StringVar(&teaThemeName, "theme", defaultTheme,
+		"colour theme, one of "+strings.Join(themeNames(), ", "))
+}
 
 type model struct {
 	db          *sql.DB
+	overview    OverviewView
 	stepsChart  StepsChart
 	weightChart WeightChart
 	heartChart  HeartChart
+	activity    ActivityView
+	recovery    RecoveryView
+	budget      BudgetView
+	habitica    HabiticaView
 	activeState activeState
+	date        viewDate
+	calendar    *calendar
+	help        help.Model
+	showHelp    bool
+	zones       *zone.Manager
+	width       int
+	height      int
+	layout      layoutMode
+	syncEvery   time.Duration
+	syncing     bool
+	lastSynced  time.Time
+	syncErr     error
 }
 
 func (a activeState) String() string {
 	switch a {
+	case todayActive:
+		return "Today"
 	case stepsActive:
 		return "Steps"
 	case weightActive:
 		return "Weight"
 	case heartActive:
 		return "Heart"
+	case activityActive:
+		return "Activity"
 	case sleepActive:
 		return "Sleep"
+	case recoveryActive:
+		return "Recovery"
+	case budgetActive:
+		return "Budget"
+	case habiticaActive:
+		return "Habitica"
 	case numStates:
 		return "None"
 	default:
@@ -68,135 +143,385 @@ func (m model) decrementState() activeState {
 }
 
 func (m model) Init() tea.Cmd {
-	m.stepsChart.Draw()
-	m.weightChart.DrawXYAxisAndLabel()
-	m.weightChart.DrawBrailleAll()
-	m.heartChart.Draw()
-	return nil
+	cmds := []tea.Cmd{m.loadAll(), m.habitica.Init()}
+	if m.syncEvery > 0 {
+		cmds = append(cmds, backgroundSync(m.db))
+	}
+	return tea.Batch(cmds...)
+}
+
+// loadAll (re)loads the data of every panel.
+func (m model) loadAll() tea.Cmd {
+	return tea.Batch(
+		m.overview.Init(),
+		m.stepsChart.Init(),
+		m.weightChart.Init(),
+		m.heartChart.Init(),
+		m.activity.Init(),
+		m.recovery.Init(),
+		m.budget.Init(),
+	)
+}
+
+// reloadAll shows every panel as loading and reloads its data, e.g.
+// after a sync stored new data.
+func (m *model) reloadAll() tea.Cmd {
+	m.overview.spinner.loading = true
+	m.stepsChart.spinner.loading = true
+	m.weightChart.spinner.loading = true
+	m.heartChart.spinner.loading = true
+	m.activity.spinner.loading = true
+	m.recovery.spinner.loading = true
+	m.budget.spinner.loading = true
+	return tea.Batch(m.loadAll(), m.habitica.Init())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	forwardmsg := false
 	activeChange := false
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case habiticaLoadedMsg, habiticaScoredMsg:
+		m.habitica, cmd = m.habitica.Update(msg)
+		m.overview.setHabiticaTasks(m.habitica.tasks)
+		return m, cmd
+	case overviewDataMsg:
+		m.overview, cmd = m.overview.Update(msg)
+		m.overview.setHabiticaTasks(m.habitica.tasks)
+		m.stepsChart.SetGoal(m.overview.summary.StepsGoal)
+		return m, cmd
+	case calendarPickedMsg:
+		m.calendar = nil
+		date := m.date
+		date.day = time.Time(msg)
+		return m, m.setDate(date)
+	case calendarClosedMsg:
+		m.calendar = nil
+		return m, nil
+	case jumpTabMsg:
+		m.activeState = activeState(msg)
+		m.focusActive()
+		return m, nil
+	case stepsDataMsg:
+		m.stepsChart, cmd = m.stepsChart.Update(msg)
+		m.stepsChart.Draw()
+		return m, cmd
+	case weightDataMsg:
+		m.weightChart, cmd = m.weightChart.Update(msg)
+		m.weightChart.DrawBrailleAll()
+		return m, cmd
+	case heartDataMsg:
+		m.heartChart, cmd = m.heartChart.Update(msg)
+		m.heartChart.Draw()
+		return m, cmd
+	case activityDataMsg, activityDetailMsg:
+		m.activity, cmd = m.activity.Update(msg)
+		return m, cmd
+	case recoveryDataMsg:
+		m.recovery, cmd = m.recovery.Update(msg)
+		return m, cmd
+	case budgetDataMsg:
+		m.budget, cmd = m.budget.Update(msg)
+		return m, cmd
+	case tea.MouseMsg:
+		if m.calendar == nil && !m.showHelp {
+			m.updateMouse(msg)
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+	case spinner.TickMsg:
+		// each spinner ignores the ticks of the others
+		var cmds [7]tea.Cmd
+		m.stepsChart, cmds[0] = m.stepsChart.Update(msg)
+		m.weightChart, cmds[1] = m.weightChart.Update(msg)
+		m.heartChart, cmds[2] = m.heartChart.Update(msg)
+		m.recovery, cmds[3] = m.recovery.Update(msg)
+		m.budget, cmds[4] = m.budget.Update(msg)
+		m.overview, cmds[5] = m.overview.Update(msg)
+		m.activity, cmds[6] = m.activity.Update(msg)
+		return m, tea.Batch(cmds[:]...)
+	case syncTickMsg:
+		if m.syncing {
+			return m, nil
+		}
+		m.syncing = true
+		return m, backgroundSync(m.db)
+	case syncDoneMsg:
+		m.syncing = false
+		m.syncErr = msg.err
+		if msg.err == nil {
+			m.lastSynced = msg.at
+		} else {
+			slog.Warn("background sync failed", "err", msg.err)
+		}
+		return m, tea.Batch(m.reloadAll(), syncTick(m.syncEvery))
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "pgup", "pgdn":
+		if m.showHelp {
+			switch {
+			case key.Matches(msg, teaKeys.Quit):
+				return m, tea.Quit
+			case key.Matches(msg, teaKeys.Help, teaKeys.Close):
+				m.showHelp = false
+			}
+			return m, nil
+		}
+		if key.Matches(msg, teaKeys.Help) {
+			m.showHelp = true
+			return m, nil
+		}
+		// the calendar takes every other key while it is open
+		if m.calendar != nil {
+			*m.calendar, cmd = m.calendar.Update(msg)
+			return m, cmd
+		}
+		if cmd, ok := m.dateKey(msg); ok {
+			return m, cmd
+		}
+		// the today, activity and habitica tabs have keys of their own
+		if m.activeState == todayActive || m.activeState == activityActive || m.activeState == habiticaActive {
 			forwardmsg = true
-		case "down", "j":
+		}
+		switch {
+		case key.Matches(msg, teaKeys.ChartZoomIn, teaKeys.ChartZoomOut, teaKeys.Compare):
 			forwardmsg = true
-		case "up", "k":
+		case key.Matches(msg, teaKeys.Up, teaKeys.Down, teaKeys.Left, teaKeys.Right):
 			forwardmsg = true
-		case "q":
+		case key.Matches(msg, teaKeys.Quit):
 			return m, tea.Quit
-		case "right", "l":
-			forwardmsg = true
-		case "left", "h":
-			forwardmsg = true
-		case "tab":
+		case key.Matches(msg, teaKeys.NextTab):
 			m.activeState = m.incrementState()
 			activeChange = true
-		case "shift+tab":
+			forwardmsg = false
+		case key.Matches(msg, teaKeys.PrevTab):
 			m.activeState = m.decrementState()
 			activeChange = true
+			forwardmsg = false
 		}
 	}
 	if activeChange {
-		m.weightChart.Blur()
-		m.stepsChart.Canvas.Blur()
-		m.heartChart.Blur()
-		switch m.activeState {
-		case stepsActive:
-			m.stepsChart.Canvas.Focus()
-		case weightActive:
-			m.weightChart.Focus()
-		case heartActive:
-			m.heartChart.Focus()
-		}
+		m.focusActive()
 	}
 	if forwardmsg {
 		switch m.activeState {
+		case todayActive:
+			m.overview, cmd = m.overview.Update(msg)
 		case weightActive:
-			m.weightChart.Model, _ = m.weightChart.Model.Update(msg)
+			m.weightChart, cmd = m.weightChart.Update(msg)
 			m.weightChart.DrawBrailleAll()
 		case stepsActive:
-			m.stepsChart, _ = m.stepsChart.Update(msg)
+			m.stepsChart, cmd = m.stepsChart.Update(msg)
 			m.stepsChart.Draw()
 		case heartActive:
-			m.heartChart, _ = m.heartChart.Update(msg)
+			m.heartChart, cmd = m.heartChart.Update(msg)
 			m.heartChart.Draw()
+		case activityActive:
+			m.activity, cmd = m.activity.Update(msg)
+		case recoveryActive:
+			m.recovery, cmd = m.recovery.Update(msg)
+		case budgetActive:
+			m.budget, cmd = m.budget.Update(msg)
+			m.budget.Draw()
+		case habiticaActive:
+			m.habitica, cmd = m.habitica.Update(msg)
 		}
 	}
-	return m, nil
+	return m, cmd
 }
 
-func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
-	border := lipgloss.RoundedBorder()
-	border.BottomLeft = left
-	border.Bottom = middle
-	border.BottomRight = right
-	return border
+// datedTab reports whether the tab shows the shared date rather than today.
+func datedTab(state activeState) bool {
+	return state != todayActive && state != habiticaActive
 }
 
-var (
-	inactiveTabBorder = tabBorderWithBottom("┴", "─", "┴")
-	activeTabBorder   = tabBorderWithBottom("┘", " ", "└")
-	docStyle          = lipgloss.NewStyle().Padding(1, 2, 1, 2)
-	highlightColor    = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
-	inactiveTabStyle  = lipgloss.NewStyle().Border(inactiveTabBorder, true).BorderForeground(highlightColor).Padding(0, 1)
-	activeTabStyle    = inactiveTabStyle.Copy().Border(activeTabBorder, true)
-	windowStyle       = lipgloss.NewStyle().BorderForeground(highlightColor).Padding(2, 0).Align(lipgloss.Center).Border(lipgloss.NormalBorder()).UnsetBorderTop()
-)
-
-func (m model) View() string {
-	// TODO: make header
-	width, _, _ := term.GetSize(int(os.Stdout.Fd()))
-	doc := strings.Builder{}
-
-	var renderedTabs []string
+// dateKey handles the keys that change the shared date, reporting whether
+// msg was one of them.
+func (m *model) dateKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	date := m.date
+	switch {
+	case key.Matches(msg, teaKeys.Calendar):
+		c := newCalendar(m.date.day)
+		m.calendar = &c
+		return nil, true
+	case key.Matches(msg, teaKeys.Today):
+		date.day = truncateDay(time.Now())
+	case key.Matches(msg, teaKeys.WeekBack):
+		date.day = date.day.AddDate(0, 0, -7)
+	case key.Matches(msg, teaKeys.WeekForward):
+		date.day = date.day.AddDate(0, 0, 7)
+	case key.Matches(msg, teaKeys.ZoomOut):
+		date.zoom = min(date.zoom+1, numZooms-1)
+	case key.Matches(msg, teaKeys.ZoomIn):
+		date.zoom = max(date.zoom-1, zoomDay)
+	case key.Matches(msg, teaKeys.Up, teaKeys.Down):
+		// the activity tab moves through its list instead
+		if !datedTab(m.activeState) || m.activeState == activityActive {
+			return nil, false
+		}
+		n := 1
+		if key.Matches(msg, teaKeys.Down) {
+			n = -1
+		}
+		if m.activeState == budgetActive && date.zoom < zoomMonth {
+			// the budget has nothing finer than a month
+			date.day = addMonths(date.day, n)
+		} else {
+			date = date.step(n)
+		}
+	default:
+		return nil, false
+	}
+	return m.setDate(date), true
+}
 
-	// TODO: build border for any content
+// setDate shows date on every tab that follows the shared date.
+func (m *model) setDate(date viewDate) tea.Cmd {
+	m.date = date
+	return tea.Batch(
+		m.stepsChart.SetDate(date),
+		m.weightChart.SetDate(date),
+		m.heartChart.SetDate(date),
+		m.activity.SetDate(date),
+		m.recovery.SetDate(date),
+		m.budget.SetDate(date),
+	)
+}
 
-	// which tab is active
+// focusActive focuses the active tab's panel and blurs the others.
+func (m *model) focusActive() {
+	m.overview.Blur()
+	m.weightChart.Blur()
+	m.stepsChart.Canvas.Blur()
+	m.heartChart.Blur()
+	m.activity.Blur()
+	m.recovery.Blur()
+	m.budget.Canvas.Blur()
+	m.habitica.Blur()
 	switch m.activeState {
+	case todayActive:
+		m.overview.Focus()
 	case stepsActive:
-		doc.WriteString(defaultStyle.Render(m.stepsChart.View()))
+		m.stepsChart.Canvas.Focus()
 	case weightActive:
-		doc.WriteString(defaultStyle.Render(m.weightChart.View()))
+		m.weightChart.Focus()
 	case heartActive:
-		doc.WriteString(defaultStyle.Render(m.heartChart.View()))
+		m.heartChart.Focus()
+	case activityActive:
+		m.activity.Focus()
+	case recoveryActive:
+		m.recovery.Focus()
+	case budgetActive:
+		m.budget.Canvas.Focus()
+	case habiticaActive:
+		m.habitica.Focus()
 	}
+}
 
-	// build the tabs
-	for state := stepsActive; state < numStates; state++ {
-		var style lipgloss.Style
-		style = style.BorderStyle(lipgloss.HiddenBorder())
-		if state == m.activeState {
-			style = style.BorderStyle(lipgloss.Border{Bottom: "_"}).BorderForeground(lipgloss.Color("63"))
-		}
-		renderedTabs = append(renderedTabs, style.Render(state.String()))
-	}
+var docStyle = lipgloss.NewStyle().Padding(1, 2, 1, 2)
 
-	row := lipgloss.JoinHorizontal(lipgloss.Top, renderedTabs...)
+func (m model) View() string {
+	// TODO: make header
+	doc := strings.Builder{}
+	doc.WriteString(m.panels())
 	doc.WriteString("\n")
-	doc.WriteString(row)
+	doc.WriteString(m.tabs())
 	doc.WriteString("\n")
+	if datedTab(m.activeState) {
+		doc.WriteString(syncStatusStyle.Render(m.date.String()+", "+m.date.zoom.String()+" zoom") + "   ")
+	}
+	doc.WriteString(m.syncStatus())
+	doc.WriteString("\n")
+	doc.WriteString(m.helpFooter())
 
-	return docStyle.Width(width).Align(lipgloss.Center).Render(doc.String())
+	style, _ := m.frame()
+	view := style.Width(m.width).Align(lipgloss.Center).Render(doc.String())
+	if m.zones != nil {
+		view = m.zones.Scan(view)
+	}
+	return view
+}
+
+// syncStatus is the footer line showing when data was last synced.
+func (m model) syncStatus() string {
+	var status string
+	switch {
+	case m.syncing:
+		status = "syncing…"
+	case m.syncErr != nil:
+		// the whole error is in the log, the footer has room for a line
+		line, _, _ := strings.Cut(m.syncErr.Error(), "\n")
+		return overspentStyle.Render("sync failed: " + line)
+	case !m.lastSynced.IsZero():
+		status = "last synced " + m.lastSynced.Format("15:04")
+	case m.syncEvery == 0:
+		status = "background sync off"
+	}
+	return syncStatusStyle.Render(status)
 }
 
 func runTea(cmd *cobra.Command, args []string) {
 	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
 
-	db := db.GetDb()
+	// a missing keys file only matters when it was asked for
+	keys, err := loadKeyMap(teaKeysFile)
+	if err != nil && (!errors.Is(err, fs.ErrNotExist) || cmd.Flags().Changed("keys")) {
+		log.Fatal(err)
+	}
+	teaKeys = keys
 
-	weightChart := NewWeightChart(db, width-10, height-10)
-	stepsChart := NewStepsChart(db, width-20, height-10)
-	heartChart := NewHeartChart(db, width-10, height-10, 0, 1)
+	t, err := lookupTheme(teaThemeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	applyTheme(t)
 
-	stepsChart.Canvas.Focus()
-	m := model{db, stepsChart, weightChart, heartChart, stepsActive}
-	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+	// the TUI owns the terminal, so rate limit warnings and the like from
+	// background syncs go to a file instead of over it
+	closeLog, err := logToFile("debug.log")
+	if err != nil {
 		log.Fatal(err)
 	}
+	defer closeLog()
+
+	db := db.GetDb()
+
+	// panels are sized by setSize, and again on every tea.WindowSizeMsg
+	m := model{
+		db:          db,
+		overview:    NewOverviewView(db, width, height),
+		stepsChart:  NewStepsChart(db, width, height),
+		weightChart: NewWeightChart(db, width, height),
+		heartChart:  NewHeartChart(db, width, height, 0, 1),
+		activity:    NewActivityView(db, width, height),
+		recovery:    NewRecoveryView(db, width),
+		budget:      NewBudgetView(db, width, height),
+		habitica:    NewHabiticaView(width, height),
+		activeState: todayActive,
+		date:        todayViewDate(zoomDay),
+		help:        help.New(),
+		syncEvery:   teaSyncEvery,
+		syncing:     teaSyncEvery > 0,
+	}
+	m.focusActive()
+	m.setSize(width, height)
+	m.setZoneManager(zone.New())
+	if _, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseAllMotion()).Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// logToFile points slog at path, at the level LOGLEVEL asks for, and
+// returns a func that closes the file.
+func logToFile(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	level := slog.LevelInfo
+	if os.Getenv("LOGLEVEL") == "DEBUG" {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: level})))
+	return f.Close, nil
 }
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/daterange"
+)
+
+type zoomLevel int
+
+const (
+	zoomDay zoomLevel = iota
+	zoomWeek
+	zoomMonth
+	zoomYear
+	numZooms
+)
+
+func (z zoomLevel) String() string {
+	switch z {
+	case zoomDay:
+		return "day"
+	case zoomWeek:
+		return "week"
+	case zoomMonth:
+		return "month"
+	case zoomYear:
+		return "year"
+	default:
+		return "unknown zoom"
+	}
+}
+
+// viewDate is the date and zoom shared by the tabs. The range shown is
+// the day, week, month or year containing day.
+type viewDate struct {
+	day  time.Time
+	zoom zoomLevel
+}
+
+func todayViewDate(zoom zoomLevel) viewDate {
+	return viewDate{day: truncateDay(time.Now()), zoom: zoom}
+}
+
+// Start returns the first day of the range.
+func (v viewDate) Start() time.Time {
+	switch v.zoom {
+	case zoomWeek:
+		return daterange.WeekStart(v.day)
+	case zoomMonth:
+		return time.Date(v.day.Year(), v.day.Month(), 1, 0, 0, 0, 0, time.Local)
+	case zoomYear:
+		return time.Date(v.day.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	}
+	return v.day
+}
+
+// End returns the day after the range.
+func (v viewDate) End() time.Time {
+	start := v.Start()
+	switch v.zoom {
+	case zoomWeek:
+		return start.AddDate(0, 0, 7)
+	case zoomMonth:
+		return start.AddDate(0, 1, 0)
+	case zoomYear:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// step moves the date by n days, weeks, months or years depending on
+// the zoom.
+func (v viewDate) step(n int) viewDate {
+	switch v.zoom {
+	case zoomWeek:
+		v.day = v.day.AddDate(0, 0, 7*n)
+	case zoomMonth:
+		v.day = addMonths(v.day, n)
+	case zoomYear:
+		v.day = addMonths(v.day, 12*n)
+	default:
+		v.day = v.day.AddDate(0, 0, n)
+	}
+	return v
+}
+
+// addMonths moves day by n months, keeping to the last day of a shorter
+// month: Mar 31 less a month is Feb 28 rather than Mar 3, and Feb 29
+// plus a year is Feb 28.
+func addMonths(day time.Time, n int) time.Time {
+	first := time.Date(day.Year(), day.Month()+time.Month(n), 1, 0, 0, 0, 0, day.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(day.Day(), last),
+		day.Hour(), day.Minute(), day.Second(), day.Nanosecond(), day.Location())
+}
+
+func (v viewDate) Equal(o viewDate) bool {
+	return v.zoom == o.zoom && v.day.Equal(o.day)
+}
+
+// String describes the range, e.g. "2024-03-04", "week of 2024-03-04",
+// "March 2024" or "2024".
+func (v viewDate) String() string {
+	switch v.zoom {
+	case zoomWeek:
+		return "week of " + v.Start().Format("2006-01-02")
+	case zoomMonth:
+		return v.day.Format("January 2006")
+	case zoomYear:
+		return v.day.Format("2006")
+	}
+	return v.day.Format("Mon 2006-01-02")
+}
+
+// dayDiff returns the number of days from today to day, for the queries
+// written relative to date('now').
+func dayDiff(day time.Time) int {
+	return int(math.Round(truncateDay(day).Sub(truncateDay(time.Now())).Hours() / 24))
+}
+
+// calendarPickedMsg is sent when a day is picked in the calendar.
+type calendarPickedMsg time.Time
+
+// calendarClosedMsg is sent when the calendar is closed without picking.
+type calendarClosedMsg struct{}
+
+// calendar is a month calendar to pick a day from.
+type calendar struct {
+	cursor time.Time
+}
+
+func newCalendar(day time.Time) calendar {
+	return calendar{cursor: truncateDay(day)}
+}
+
+func (c calendar) Update(msg tea.Msg) (calendar, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return c, nil
+	}
+	switch {
+	case key.Matches(keyMsg, teaKeys.Left):
+		c.cursor = c.cursor.AddDate(0, 0, -1)
+	case key.Matches(keyMsg, teaKeys.Right):
+		c.cursor = c.cursor.AddDate(0, 0, 1)
+	case key.Matches(keyMsg, teaKeys.Up):
+		c.cursor = c.cursor.AddDate(0, 0, -7)
+	case key.Matches(keyMsg, teaKeys.Down):
+		c.cursor = c.cursor.AddDate(0, 0, 7)
+	case key.Matches(keyMsg, teaKeys.PrevMonth):
+		c.cursor = addMonths(c.cursor, -1)
+	case key.Matches(keyMsg, teaKeys.NextMonth):
+		c.cursor = addMonths(c.cursor, 1)
+	case key.Matches(keyMsg, teaKeys.PrevYear):
+		c.cursor = addMonths(c.cursor, -12)
+	case key.Matches(keyMsg, teaKeys.NextYear):
+		c.cursor = addMonths(c.cursor, 12)
+	case key.Matches(keyMsg, teaKeys.Today):
+		c.cursor = truncateDay(time.Now())
+	case key.Matches(keyMsg, teaKeys.Select):
+		day := c.cursor
+		return c, func() tea.Msg { return calendarPickedMsg(day) }
+	case key.Matches(keyMsg, teaKeys.Close):
+		return c, func() tea.Msg { return calendarClosedMsg{} }
+	}
+	return c, nil
+}
+
+var calendarCursorStyle = lipgloss.NewStyle().Reverse(true)
+
+func (c calendar) View() string {
+	var b strings.Builder
+	first := time.Date(c.cursor.Year(), c.cursor.Month(), 1, 0, 0, 0, 0, time.Local)
+	b.WriteString(lipgloss.PlaceHorizontal(20, lipgloss.Center, first.Format("January 2006")) + "\n")
+	b.WriteString("Mo Tu We Th Fr Sa Su\n")
+
+	today := truncateDay(time.Now())
+	day := daterange.WeekStart(first)
+	for day.Before(first.AddDate(0, 1, 0)) {
+		var week []string
+		for i := 0; i < 7; i++ {
+			cell := "  "
+			if day.Month() == first.Month() {
+				cell = fmt.Sprintf("%2d", day.Day())
+				switch {
+				case day.Equal(c.cursor):
+					cell = calendarCursorStyle.Render(cell)
+				case day.Equal(today):
+					cell = calendarTodayStyle.Render(cell)
+				}
+			}
+			week = append(week, cell)
+			day = day.AddDate(0, 0, 1)
+		}
+		b.WriteString(strings.Join(week, " ") + "\n")
+	}
+	b.WriteString(mutedStyle.Render(fmt.Sprintf("%s %s month  %s %s year\n%s pick  %s close",
+		teaKeys.PrevMonth.Help().Key, teaKeys.NextMonth.Help().Key,
+		teaKeys.PrevYear.Help().Key, teaKeys.NextYear.Help().Key,
+		teaKeys.Select.Help().Key, teaKeys.Close.Help().Key)))
+	return calendarStyle.Render(b.String())
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NimbleMarkets/ntcharts/canvas"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyMap holds every key binding of the TUI. A binding used by several
+// panels, like left and right, means the same on each of them.
+type keyMap struct {
+	NextTab key.Binding
+	PrevTab key.Binding
+	Help    key.Binding
+	Quit    key.Binding
+
+	// the shared date
+	Calendar    key.Binding
+	Today       key.Binding
+	WeekBack    key.Binding
+	WeekForward key.Binding
+	ZoomOut     key.Binding
+	ZoomIn      key.Binding
+
+	// the active panel; up and down step the date on dated tabs
+	Left         key.Binding
+	Right        key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	ChartZoomIn  key.Binding
+	ChartZoomOut key.Binding
+	Select       key.Binding
+	Refresh      key.Binding
+	ScoreUp      key.Binding
+	ScoreDown    key.Binding
+	Checklist    key.Binding
+	Compare      key.Binding
+
+	// the calendar
+	PrevMonth key.Binding
+	NextMonth key.Binding
+	PrevYear  key.Binding
+	NextYear  key.Binding
+	Close     key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		NextTab: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next tab")),
+		PrevTab: key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "previous tab")),
+		Help:    key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+		Quit:    key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+
+		Calendar:    key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "calendar")),
+		Today:       key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "today")),
+		WeekBack:    key.NewBinding(key.WithKeys("["), key.WithHelp("[", "week back")),
+		WeekForward: key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "week forward")),
+		ZoomOut:     key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "zoom out")),
+		ZoomIn:      key.NewBinding(key.WithKeys("Z"), key.WithHelp("Z", "zoom in")),
+
+		Left:         key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "left")),
+		Right:        key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "right")),
+		Up:           key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:         key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		ChartZoomIn:  key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "zoom chart in")),
+		ChartZoomOut: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdown", "zoom chart out")),
+		Select:       key.NewBinding(key.WithKeys("enter", " ", "x"), key.WithHelp("enter", "select")),
+		Refresh:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+		ScoreUp:      key.NewBinding(key.WithKeys("+"), key.WithHelp("+", "score up")),
+		ScoreDown:    key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "score down")),
+		Checklist:    key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "check next item")),
+		Compare:      key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "compare")),
+
+		PrevMonth: key.NewBinding(key.WithKeys("[", "pgup"), key.WithHelp("[", "previous month")),
+		NextMonth: key.NewBinding(key.WithKeys("]", "pgdown"), key.WithHelp("]", "next month")),
+		PrevYear:  key.NewBinding(key.WithKeys("{"), key.WithHelp("{", "previous year")),
+		NextYear:  key.NewBinding(key.WithKeys("}"), key.WithHelp("}", "next year")),
+		Close:     key.NewBinding(key.WithKeys("esc", "g", "q"), key.WithHelp("esc", "close")),
+	}
+}
+
+// teaKeys are the bindings in use, the defaults until runTea reads the
+// keys file.
+var teaKeys = defaultKeyMap()
+
+// bindings returns the bindings of k by the names used in the keys file.
+func (k *keyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"next_tab":       &k.NextTab,
+		"prev_tab":       &k.PrevTab,
+		"help":           &k.Help,
+		"quit":           &k.Quit,
+		"calendar":       &k.Calendar,
+		"today":          &k.Today,
+		"week_back":      &k.WeekBack,
+		"week_forward":   &k.WeekForward,
+		"zoom_out":       &k.ZoomOut,
+		"zoom_in":        &k.ZoomIn,
+		"left":           &k.Left,
+		"right":          &k.Right,
+		"up":             &k.Up,
+		"down":           &k.Down,
+		"chart_zoom_in":  &k.ChartZoomIn,
+		"chart_zoom_out": &k.ChartZoomOut,
+		"select":         &k.Select,
+		"refresh":        &k.Refresh,
+		"score_up":       &k.ScoreUp,
+		"score_down":     &k.ScoreDown,
+		"checklist":      &k.Checklist,
+		"compare":        &k.Compare,
+		"prev_month":     &k.PrevMonth,
+		"next_month":     &k.NextMonth,
+		"prev_year":      &k.PrevYear,
+		"next_year":      &k.NextYear,
+		"close":          &k.Close,
+	}
+}
+
+// loadKeyMap returns the default bindings with those in the JSON file at
+// path replaced. An empty list of keys disables a binding.
+func loadKeyMap(path string) (keyMap, error) {
+	k := defaultKeyMap()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return k, err
+	}
+	var remap map[string][]string
+	if err := json.Unmarshal(b, &remap); err != nil {
+		return k, fmt.Errorf("reading %s: %w", path, err)
+	}
+	bindings := k.bindings()
+	for name, keys := range remap {
+		binding, ok := bindings[name]
+		if !ok {
+			return k, fmt.Errorf("%s: unknown binding %q", path, name)
+		}
+		if len(keys) == 0 {
+			binding.SetEnabled(false)
+			continue
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+	return k, nil
+}
+
+// canvasKeyMap returns the bindings ntcharts uses to pan and zoom a chart.
+func (k keyMap) canvasKeyMap() canvas.KeyMap {
+	return canvas.KeyMap{
+		Up:     k.Up,
+		Down:   k.Down,
+		Left:   k.Left,
+		Right:  k.Right,
+		PgUp:   k.ChartZoomIn,
+		PgDown: k.ChartZoomOut,
+	}
+}
+
+// withDesc returns a copy of b described as desc in the help.
+func withDesc(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+// tabKeys is the help.KeyMap of the keys that do something on a tab.
+type tabKeys struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+func (t tabKeys) ShortHelp() []key.Binding  { return t.short }
+func (t tabKeys) FullHelp() [][]key.Binding { return t.full }
+
+// helpKeys returns the keys of the active tab, or of the calendar while
+// it is open.
+func (m model) helpKeys() tabKeys {
+	k := teaKeys
+	if m.calendar != nil {
+		return tabKeys{
+			short: []key.Binding{k.Select, k.Close, k.Help},
+			full: [][]key.Binding{
+				{withDesc(k.Left, "previous day"), withDesc(k.Right, "next day"),
+					withDesc(k.Up, "previous week"), withDesc(k.Down, "next week")},
+				{k.PrevMonth, k.NextMonth, k.PrevYear, k.NextYear},
+				{k.Today, withDesc(k.Select, "pick"), k.Close},
+			},
+		}
+	}
+
+	var panel []key.Binding
+	switch m.activeState {
+	case todayActive:
+		panel = []key.Binding{k.Left, k.Right, k.Up, k.Down, withDesc(k.Select, "open tab"), k.Refresh}
+	case stepsActive:
+		panel = []key.Binding{withDesc(k.Left, "previous bar"), withDesc(k.Right, "next bar"),
+			withDesc(k.Compare, "30-day average")}
+	case heartActive:
+		panel = []key.Binding{withDesc(k.Left, "pan left"), withDesc(k.Right, "pan right"),
+			k.ChartZoomIn, k.ChartZoomOut, withDesc(k.Compare, "compare with another day")}
+	case weightActive:
+		panel = []key.Binding{withDesc(k.Left, "pan left"), withDesc(k.Right, "pan right"),
+			k.ChartZoomIn, k.ChartZoomOut}
+	case activityActive:
+		panel = []key.Binding{withDesc(k.Up, "previous activity"), withDesc(k.Down, "next activity"),
+			withDesc(k.Select, "show heart rate")}
+	case habiticaActive:
+		panel = []key.Binding{k.Up, k.Down, withDesc(k.Select, "check"),
+			k.ScoreUp, k.ScoreDown, k.Checklist, k.Refresh}
+	}
+	short := append([]key.Binding{}, panel...)
+	var full [][]key.Binding
+	if len(panel) > 0 {
+		full = append(full, panel)
+	}
+	if datedTab(m.activeState) {
+		date := []key.Binding{k.WeekBack, k.WeekForward, k.ZoomOut, k.ZoomIn, k.Today, k.Calendar}
+		// up and down move through the activity list rather than the date
+		if m.activeState != activityActive {
+			later, earlier := withDesc(k.Up, "later"), withDesc(k.Down, "earlier")
+			short = append(short, later, earlier)
+			date = append([]key.Binding{later, earlier}, date...)
+		}
+		short = append(short, k.Calendar)
+		full = append(full, date)
+	}
+	short = append(short, k.NextTab, k.Help, k.Quit)
+	full = append(full, []key.Binding{k.NextTab, k.PrevTab, k.Help, k.Quit})
+	return tabKeys{short: short, full: full}
+}
+
+// helpView renders the full help of the active tab for the ? overlay.
+func (m model) helpView() string {
+	title := lipgloss.NewStyle().Bold(true).Render(m.activeState.String() + " keys")
+	if m.calendar != nil {
+		title = lipgloss.NewStyle().Bold(true).Render("calendar keys")
+	}
+	h := m.help
+	h.ShowAll = true
+	return helpOverlayStyle.Render(title + "\n\n" + h.View(m.helpKeys()))
+}
+
+// helpFooter renders the short help line below the tabs.
+func (m model) helpFooter() string {
+	h := m.help
+	h.ShowAll = false
+	doc, _ := m.frame()
+	h.Width = m.width - doc.GetHorizontalFrameSize()
+	return h.View(m.helpKeys())
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+type layoutMode int
+
+const (
+	// compactLayout drops padding and side panels on small terminals
+	compactLayout layoutMode = iota
+	singleLayout
+	// gridLayout shows related panels side by side on wide terminals
+	gridLayout
+)
+
+const (
+	compactWidth  = 80
+	compactHeight = 24
+	gridWidth     = 160
+	// panels narrower than this leave out what they show beside their chart
+	compactPanelWidth = 60
+)
+
+func layoutFor(width, height int) layoutMode {
+	switch {
+	case width < compactWidth || height < compactHeight:
+		return compactLayout
+	case width >= gridWidth:
+		return gridLayout
+	default:
+		return singleLayout
+	}
+}
+
+// gridPartner returns the panel shown beside state in the grid layout.
+func gridPartner(state activeState) (activeState, bool) {
+	switch state {
+	case stepsActive:
+		return heartActive, true
+	case heartActive:
+		return stepsActive, true
+	case weightActive:
+		return recoveryActive, true
+	case recoveryActive:
+		return weightActive, true
+	}
+	return 0, false
+}
+
+var compactTabStyle = lipgloss.NewStyle().Padding(0, 1)
+
+// frame returns the style around the whole view and the height taken by
+// the tabs and footer below the panels.
+func (m model) frame() (lipgloss.Style, int) {
+	if m.layout == compactLayout {
+		return lipgloss.NewStyle(), 3
+	}
+	return docStyle, 5
+}
+
+// panelSize returns the size inside the border of each panel shown.
+func (m model) panelSize() (int, int) {
+	doc, below := m.frame()
+	width := m.width - doc.GetHorizontalFrameSize() - defaultStyle.GetHorizontalFrameSize()
+	height := m.height - doc.GetVerticalFrameSize() - below - defaultStyle.GetVerticalFrameSize()
+	if m.layout == gridLayout {
+		width = (width - defaultStyle.GetHorizontalFrameSize()) / 2
+	}
+	return max(width, 1), max(height, 1)
+}
+
+// setSize lays the view out for a width by height terminal and resizes
+// every panel to fit.
+func (m *model) setSize(width, height int) {
+	m.width, m.height = width, height
+	m.layout = layoutFor(width, height)
+	w, h := m.panelSize()
+	m.stepsChart.SetSize(w, h)
+	m.weightChart.SetSize(w, h)
+	m.heartChart.SetSize(w, h)
+	m.recovery.SetSize(w, h)
+	// the today, activity, budget and habitica tabs have no partner and get
+	// the full width
+	if m.layout == gridLayout {
+		w = w*2 + defaultStyle.GetHorizontalFrameSize()
+	}
+	m.overview.SetSize(w, h)
+	m.activity.SetSize(w, h)
+	m.budget.SetSize(w, h)
+	m.habitica.SetSize(w, h)
+}
+
+// panelView renders the panel of state without its border.
+func (m model) panelView(state activeState) string {
+	switch state {
+	case todayActive:
+		return m.overview.View()
+	case stepsActive:
+		return m.stepsChart.View()
+	case weightActive:
+		return m.weightChart.View()
+	case heartActive:
+		return m.heartChart.View()
+	case activityActive:
+		return m.activity.View()
+	case recoveryActive:
+		return m.recovery.View()
+	case budgetActive:
+		return m.budget.View()
+	case habiticaActive:
+		return m.habitica.View()
+	}
+	return ""
+}
+
+// panels renders the active panel, beside its partner in the grid layout,
+// or the help or calendar over them.
+func (m model) panels() string {
+	w, h := m.panelSize()
+	var overlay string
+	switch {
+	case m.showHelp:
+		overlay = m.helpView()
+	case m.calendar != nil:
+		overlay = m.calendar.View()
+	}
+	if overlay != "" {
+		if m.layout == gridLayout {
+			w = w*2 + defaultStyle.GetHorizontalFrameSize()
+		}
+		return defaultStyle.Width(w).Height(h).Render(
+			lipgloss.Place(w, h, lipgloss.Center, lipgloss.Center, overlay))
+	}
+	partner, ok := gridPartner(m.activeState)
+	if m.layout != gridLayout || !ok {
+		if m.layout == gridLayout {
+			w = w*2 + defaultStyle.GetHorizontalFrameSize()
+		}
+		return defaultStyle.Width(w).Height(h).Render(m.panelView(m.activeState))
+	}
+	active := defaultStyle.Width(w).Height(h).Render(m.panelView(m.activeState))
+	other := inactivePanelStyle.Width(w).Height(h).Render(m.panelView(partner))
+	// keep the panels in the same order whichever of them is active
+	if partner < m.activeState {
+		return lipgloss.JoinHorizontal(lipgloss.Top, other, active)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, active, other)
+}
+
+// tabs renders the tab row, a single line in the compact layout.
+func (m model) tabs() string {
+	var renderedTabs []string
+	for state := todayActive; state < numStates; state++ {
+		if m.layout == compactLayout {
+			style := compactTabStyle
+			if state == m.activeState {
+				style = style.Copy().Reverse(true)
+			}
+			renderedTabs = append(renderedTabs, style.Render(state.String()[:3]))
+			continue
+		}
+		var style lipgloss.Style
+		style = style.BorderStyle(lipgloss.HiddenBorder())
+		if state == m.activeState {
+			style = style.BorderStyle(lipgloss.Border{Bottom: "_"}).BorderForeground(teaTheme.Accent)
+		}
+		renderedTabs = append(renderedTabs, style.Render(state.String()))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, renderedTabs...)
+}
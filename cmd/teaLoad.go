@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// loadingSpinner is shown by a panel while its data loads in a tea.Cmd.
+type loadingSpinner struct {
+	spinner.Model
+	loading bool
+}
+
+// newLoadingSpinner returns a spinner that is loading, as panels are
+// created empty and load their data in Init.
+func newLoadingSpinner() loadingSpinner {
+	return loadingSpinner{
+		Model: spinner.New(
+			spinner.WithSpinner(spinner.Dot),
+			spinner.WithStyle(lipgloss.NewStyle().Foreground(teaTheme.Accent)),
+		),
+		loading: true,
+	}
+}
+
+// start marks the panel as loading and returns the command that
+// animates the spinner.
+func (l *loadingSpinner) start() tea.Cmd {
+	l.loading = true
+	return l.Tick
+}
+
+func (l *loadingSpinner) stop() {
+	l.loading = false
+}
+
+// update advances the spinner while loading. Ticks for other spinners
+// are ignored, so every panel can be sent every tick.
+func (l loadingSpinner) update(msg spinner.TickMsg) (loadingSpinner, tea.Cmd) {
+	if !l.loading {
+		return l, nil
+	}
+	var cmd tea.Cmd
+	l.Model, cmd = l.Model.Update(msg)
+	return l, cmd
+}
+
+// title renders a panel title, with the spinner while loading.
+func (l loadingSpinner) title(s string) string {
+	if l.loading {
+		return l.View() + " " + s
+	}
+	return s
+}
+
+// syncTickMsg triggers a background sync.
+type syncTickMsg time.Time
+
+// syncDoneMsg is sent when a background sync finished.
+type syncDoneMsg struct {
+	at  time.Time
+	err error
+}
+
+func syncTick(every time.Duration) tea.Cmd {
+	return tea.Tick(every, func(t time.Time) tea.Msg {
+		return syncTickMsg(t)
+	})
+}
+
+// backgroundSync syncs the last day of data without writing to the
+// terminal the TUI is drawing on.
+func backgroundSync(db *sql.DB) tea.Cmd {
+	return func() tea.Msg {
+		err := runSync(db, "-1d", io.Discard)
+		return syncDoneMsg{at: time.Now(), err: err}
+	}
+}
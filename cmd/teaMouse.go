@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NimbleMarkets/ntcharts/barchart"
+	tslc "github.com/NimbleMarkets/ntcharts/linechart/timeserieslinechart"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+// tooltip is the text shown under a chart for the point under the mouse.
+type tooltip struct {
+	x    int // column of the chart the mouse is over
+	text string
+}
+
+// View renders the tooltip on a line of width, under the mouse where it fits.
+func (t tooltip) View(width int) string {
+	if t.text == "" {
+		return ""
+	}
+	text := tooltipStyle.Render(" " + t.text + " ")
+	x := max(min(t.x, width-lipgloss.Width(text)), 0)
+	return strings.Repeat(" ", x) + text
+}
+
+// dragging reports whether msg should reach an ntcharts update handler,
+// which pans on any motion. Motion with no button held only moves the
+// tooltip.
+func dragging(msg tea.MouseMsg) bool {
+	return msg.Action != tea.MouseActionMotion || msg.Button == tea.MouseButtonLeft
+}
+
+// hoverTime returns the time under the mouse in a time series chart.
+func hoverTime(m *tslc.Model, msg tea.MouseMsg) (time.Time, int, bool) {
+	if m.ZoneManager() == nil {
+		return time.Time{}, 0, false
+	}
+	z := m.ZoneManager().Get(m.ZoneID())
+	if !z.InBounds(msg) {
+		return time.Time{}, 0, false
+	}
+	x, _ := z.Pos(msg)
+	graphX := x - m.Origin().X
+	if graphX < 0 || m.GraphWidth() < 2 {
+		return time.Time{}, 0, false
+	}
+	// the inverse of linechart's scaling of points onto the graph
+	sec := m.ViewMinX() + float64(graphX)*(m.ViewMaxX()-m.ViewMinX())/float64(m.GraphWidth()-1)
+	return time.Unix(int64(sec), 0), x, true
+}
+
+// nearestPoint returns the point of points, sorted by time, closest to t.
+func nearestPoint(points []tslc.TimePoint, t time.Time) (tslc.TimePoint, bool) {
+	if len(points) == 0 {
+		return tslc.TimePoint{}, false
+	}
+	i := sort.Search(len(points), func(i int) bool { return !points[i].Time.Before(t) })
+	switch {
+	case i == 0:
+		return points[0], true
+	case i == len(points):
+		return points[len(points)-1], true
+	case t.Sub(points[i-1].Time) < points[i].Time.Sub(t):
+		return points[i-1], true
+	}
+	return points[i], true
+}
+
+// hoverBar returns the index of the bar under the mouse in a vertical
+// bar chart.
+func hoverBar(m *barchart.Model, msg tea.MouseMsg, bars int) (int, int, bool) {
+	if m.ZoneManager() == nil {
+		return 0, 0, false
+	}
+	z := m.ZoneManager().Get(m.ZoneID())
+	if !z.InBounds(msg) {
+		return 0, 0, false
+	}
+	x, _ := z.Pos(msg)
+	idx := x / (m.BarWidth() + m.BarGap())
+	// the gap after a bar belongs to no bar
+	if idx >= bars || x%(m.BarWidth()+m.BarGap()) >= m.BarWidth() {
+		return 0, x, false
+	}
+	return idx, x, true
+}
+
+// setZoneManager enables the mouse on every chart.
+func (m *model) setZoneManager(zm *zone.Manager) {
+	m.zones = zm
+	m.stepsChart.SetZoneManager(zm)
+	m.heartChart.SetZoneManager(zm)
+	m.weightChart.SetZoneManager(zm)
+}
+
+// updateMouse sends msg to the active panel and, in the grid layout, the
+// panel beside it, so both show tooltips. Only the focused chart pans
+// and zooms.
+func (m *model) updateMouse(msg tea.MouseMsg) {
+	states := []activeState{m.activeState}
+	if partner, ok := gridPartner(m.activeState); ok && m.layout == gridLayout {
+		states = append(states, partner)
+	}
+	for _, state := range states {
+		switch state {
+		case stepsActive:
+			m.stepsChart, _ = m.stepsChart.Update(msg)
+		case heartActive:
+			m.heartChart, _ = m.heartChart.Update(msg)
+			m.heartChart.Draw()
+		case weightActive:
+			m.weightChart, _ = m.weightChart.Update(msg)
+			m.weightChart.DrawBrailleAll()
+		}
+	}
+}
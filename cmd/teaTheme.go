@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// theme holds the colour of each role the TUI and the commands' output
+// are drawn with.
+type theme struct {
+	Accent      lipgloss.TerminalColor // borders, cursors and the spinner
+	Muted       lipgloss.TerminalColor // secondary text and inactive borders
+	Subtle      lipgloss.TerminalColor // bars with no data
+	TooltipText lipgloss.TerminalColor // text on the accent
+	Good        lipgloss.TerminalColor
+	Warn        lipgloss.TerminalColor
+	Bad         lipgloss.TerminalColor
+
+	Steps           lipgloss.TerminalColor
+	StepsCumulative lipgloss.TerminalColor
+	StepsGoal       lipgloss.TerminalColor
+	Heart           lipgloss.TerminalColor
+	Comparison      lipgloss.TerminalColor // baselines and averages
+	Band            lipgloss.TerminalColor
+	Zones           []lipgloss.TerminalColor // lowest zone first
+	ZoneShades      []lipgloss.TerminalColor // backgrounds of the zones
+	Spent           lipgloss.TerminalColor
+	Available       lipgloss.TerminalColor
+	HP              lipgloss.TerminalColor
+	MP              lipgloss.TerminalColor
+	Exp             lipgloss.TerminalColor
+	Gold            lipgloss.TerminalColor
+	Heatmap         []lipgloss.TerminalColor // least done first
+
+	// Mono draws without colour, telling zones and data sets apart by
+	// their runes instead.
+	Mono bool
+}
+
+func colors(cs ...string) []lipgloss.TerminalColor {
+	var tcs []lipgloss.TerminalColor
+	for _, c := range cs {
+		tcs = append(tcs, lipgloss.Color(c))
+	}
+	return tcs
+}
+
+var themes = map[string]theme{
+	"dark": {
+		Accent: lipgloss.Color("63"), Muted: lipgloss.Color("8"), Subtle: lipgloss.Color("237"),
+		TooltipText: lipgloss.Color("0"),
+		Good:        lipgloss.Color("10"), Warn: lipgloss.Color("11"), Bad: lipgloss.Color("9"),
+		Steps: lipgloss.Color("9"), StepsCumulative: lipgloss.Color("14"), StepsGoal: lipgloss.Color("10"),
+		Heart: lipgloss.Color("11"), Comparison: lipgloss.Color("12"), Band: lipgloss.Color("8"),
+		Zones:      colors("8", "10", "214", "9"),
+		ZoneShades: colors("", "22", "94", "52"),
+		Spent:      lipgloss.Color("208"), Available: lipgloss.Color("10"),
+		HP: lipgloss.Color("9"), MP: lipgloss.Color("12"), Exp: lipgloss.Color("11"), Gold: lipgloss.Color("3"),
+		Heatmap: colors("#0e4429", "#006d32", "#26a641", "#39d353"),
+	},
+	"light": {
+		Accent: lipgloss.Color("#874BFD"), Muted: lipgloss.Color("245"), Subtle: lipgloss.Color("252"),
+		TooltipText: lipgloss.Color("15"),
+		Good:        lipgloss.Color("28"), Warn: lipgloss.Color("136"), Bad: lipgloss.Color("160"),
+		Steps: lipgloss.Color("160"), StepsCumulative: lipgloss.Color("30"), StepsGoal: lipgloss.Color("28"),
+		Heart: lipgloss.Color("130"), Comparison: lipgloss.Color("25"), Band: lipgloss.Color("248"),
+		Zones:      colors("245", "28", "166", "160"),
+		ZoneShades: colors("", "194", "223", "224"),
+		Spent:      lipgloss.Color("166"), Available: lipgloss.Color("28"),
+		HP: lipgloss.Color("160"), MP: lipgloss.Color("25"), Exp: lipgloss.Color("136"), Gold: lipgloss.Color("94"),
+		Heatmap: colors("#9be9a8", "#40c463", "#30a14e", "#216e39"),
+	},
+	// high-contrast keeps to the 16 basic colours and leaves the zones unshaded
+	"high-contrast": {
+		Accent: lipgloss.Color("15"), Muted: lipgloss.Color("7"), Subtle: lipgloss.Color("8"),
+		TooltipText: lipgloss.Color("0"),
+		Good:        lipgloss.Color("10"), Warn: lipgloss.Color("11"), Bad: lipgloss.Color("9"),
+		Steps: lipgloss.Color("9"), StepsCumulative: lipgloss.Color("14"), StepsGoal: lipgloss.Color("10"),
+		Heart: lipgloss.Color("11"), Comparison: lipgloss.Color("13"), Band: lipgloss.Color("7"),
+		Zones:      colors("7", "10", "11", "9"),
+		ZoneShades: colors(""),
+		Spent:      lipgloss.Color("11"), Available: lipgloss.Color("10"),
+		HP: lipgloss.Color("9"), MP: lipgloss.Color("12"), Exp: lipgloss.Color("11"), Gold: lipgloss.Color("3"),
+		Heatmap: colors("2", "10", "14", "15"),
+	},
+	// colourblind uses the Okabe-Ito palette, with no red against green,
+	// and viridis for the heatmap
+	"colourblind": {
+		Accent: lipgloss.Color("#56B4E9"), Muted: lipgloss.Color("8"), Subtle: lipgloss.Color("237"),
+		TooltipText: lipgloss.Color("0"),
+		Good:        lipgloss.Color("#0072B2"), Warn: lipgloss.Color("#F0E442"), Bad: lipgloss.Color("#D55E00"),
+		Steps: lipgloss.Color("#E69F00"), StepsCumulative: lipgloss.Color("#56B4E9"), StepsGoal: lipgloss.Color("#0072B2"),
+		Heart: lipgloss.Color("#F0E442"), Comparison: lipgloss.Color("#CC79A7"), Band: lipgloss.Color("8"),
+		Zones:      colors("8", "#56B4E9", "#E69F00", "#D55E00"),
+		ZoneShades: colors("", "#0b2a3a", "#3a2a00", "#3a1800"),
+		Spent:      lipgloss.Color("#E69F00"), Available: lipgloss.Color("#0072B2"),
+		HP: lipgloss.Color("#D55E00"), MP: lipgloss.Color("#0072B2"), Exp: lipgloss.Color("#F0E442"), Gold: lipgloss.Color("#E69F00"),
+		Heatmap: colors("#3b528b", "#21918c", "#5ec962", "#fde725"),
+	},
+	"mono": monoTheme(),
+}
+
+func monoTheme() theme {
+	none := lipgloss.NoColor{}
+	return theme{
+		Accent: none, Muted: none, Subtle: none, TooltipText: none,
+		Good: none, Warn: none, Bad: none,
+		Steps: none, StepsCumulative: none, StepsGoal: none,
+		Heart: none, Comparison: none, Band: none,
+		Zones:      []lipgloss.TerminalColor{none},
+		ZoneShades: []lipgloss.TerminalColor{none},
+		Spent:      none, Available: none,
+		HP: none, MP: none, Exp: none, Gold: none,
+		Heatmap: []lipgloss.TerminalColor{none},
+		Mono:    true,
+	}
+}
+
+func themeNames() []string {
+	names := []string{"auto"}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// noColor reports whether NO_COLOR asks for output without colour, see
+// https://no-color.org.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// lookupTheme returns the theme called name. NO_COLOR picks the mono theme
+// whatever the name, and auto picks dark or light by the terminal's
+// background.
+func lookupTheme(name string) (theme, error) {
+	if noColor() {
+		return themes["mono"], nil
+	}
+	if name == "auto" {
+		name = "light"
+		if lipgloss.HasDarkBackground() {
+			name = "dark"
+		}
+	}
+	t, ok := themes[name]
+	if !ok {
+		return theme{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(themeNames(), ", "))
+	}
+	return t, nil
+}
+
+// teaTheme is the theme in use, dark or mono until runTea picks one.
+var teaTheme theme
+
+func init() {
+	if noColor() {
+		applyTheme(themes["mono"])
+	} else {
+		applyTheme(themes["dark"])
+	}
+}
+
+// the styles built from the theme by applyTheme
+var (
+	defaultStyle        lipgloss.Style
+	inactivePanelStyle  lipgloss.Style
+	syncStatusStyle     lipgloss.Style
+	calendarStyle       lipgloss.Style
+	calendarTodayStyle  lipgloss.Style
+	helpOverlayStyle    lipgloss.Style
+	tooltipStyle        lipgloss.Style
+	mutedStyle          lipgloss.Style
+	goodStyle           lipgloss.Style
+	cardStyle           lipgloss.Style
+	selectedCardStyle   lipgloss.Style
+	activityHeaderStyle lipgloss.Style
+
+	stepsStyle           lipgloss.Style
+	stepsMissingStyle    lipgloss.Style
+	stepsCumulativeStyle lipgloss.Style
+	stepsGoalStyle       lipgloss.Style
+	stepsProfileStyle    lipgloss.Style
+	heartStyle           lipgloss.Style
+	heartBaselineStyle   lipgloss.Style
+	heartBandStyle       lipgloss.Style
+
+	overspentStyle lipgloss.Style
+	overPaceStyle  lipgloss.Style
+	spentStyle     lipgloss.Style
+	availableStyle lipgloss.Style
+
+	hpStyle        lipgloss.Style
+	mpStyle        lipgloss.Style
+	expStyle       lipgloss.Style
+	goldStyle      lipgloss.Style
+	cursorStyle    lipgloss.Style
+	completedStyle lipgloss.Style
+)
+
+// applyTheme makes t the theme in use and rebuilds the styles from it.
+// Panels take their chart styles from the theme when they are created.
+func applyTheme(t theme) {
+	teaTheme = t
+	fg := func(c lipgloss.TerminalColor) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(c)
+	}
+
+	defaultStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(t.Accent).
+		Align(lipgloss.Center)
+	inactivePanelStyle = defaultStyle.Copy().BorderForeground(t.Muted)
+	syncStatusStyle = fg(t.Muted)
+	calendarStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Accent).
+		Padding(0, 1)
+	calendarTodayStyle = fg(t.Accent).Bold(true)
+	helpOverlayStyle = calendarStyle.Copy()
+	tooltipStyle = fg(t.TooltipText).Background(t.Accent)
+	if t.Mono {
+		tooltipStyle = lipgloss.NewStyle().Reverse(true)
+	}
+	mutedStyle = fg(t.Muted)
+	goodStyle = fg(t.Good)
+	cardStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Muted).
+		Padding(0, 1).
+		Width(overviewCardWidth - 2).
+		Height(3)
+	selectedCardStyle = cardStyle.Copy().BorderForeground(t.Accent)
+	activityHeaderStyle = fg(t.Muted)
+
+	stepsStyle = fg(t.Steps)
+	stepsMissingStyle = fg(t.Subtle)
+	stepsCumulativeStyle = fg(t.StepsCumulative)
+	stepsGoalStyle = fg(t.StepsGoal)
+	stepsProfileStyle = fg(t.Comparison)
+	heartStyle = fg(t.Heart)
+	heartBaselineStyle = fg(t.Comparison)
+	heartBandStyle = fg(t.Band)
+
+	overspentStyle = fg(t.Bad)
+	overPaceStyle = fg(t.Warn)
+	spentStyle = fg(t.Spent)
+	availableStyle = fg(t.Available)
+
+	hpStyle = fg(t.HP)
+	mpStyle = fg(t.MP)
+	expStyle = fg(t.Exp)
+	goldStyle = fg(t.Gold)
+	cursorStyle = fg(t.Accent).Bold(true)
+	completedStyle = fg(t.Muted).Strikethrough(true)
+}
+
+// zoneRunes draw the zone lines in the mono theme, lowest zone first.
+var zoneRunes = []rune{' ', '┈', '╌', '━'}
+
+func zoneColor(i int) lipgloss.TerminalColor {
+	return teaTheme.Zones[min(i, len(teaTheme.Zones)-1)]
+}
+
+func zoneShade(i int) lipgloss.TerminalColor {
+	return teaTheme.ZoneShades[min(i, len(teaTheme.ZoneShades)-1)]
+}
+
+func zoneRune(i int) rune {
+	return zoneRunes[min(i, len(zoneRunes)-1)]
+}
+
+// zoneLabel renders the name of zone i in its colour, after the rune of
+// its line in the mono theme.
+func zoneLabel(i int, name string) string {
+	if teaTheme.Mono && i > 0 {
+		name = string(zoneRune(i)) + " " + name
+	}
+	return lipgloss.NewStyle().Foreground(zoneColor(i)).Render(name)
+}
@@ -2,47 +2,144 @@ package cmd
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"time"
 
 	tslc "github.com/NimbleMarkets/ntcharts/linechart/timeserieslinechart"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type WeightChart struct {
 	tslc.Model
+	db      *sql.DB
+	date    viewDate
+	points  []tslc.TimePoint
+	spinner loadingSpinner
+	tooltip tooltip
+}
+
+// weightDataMsg carries the weights loaded for date.
+type weightDataMsg struct {
+	date   viewDate
+	points []tslc.TimePoint
 }
 
 func (w WeightChart) View() string {
 	return lipgloss.JoinHorizontal(lipgloss.Center,
-		lipgloss.NewStyle().Align(lipgloss.Center).Render("weight per day\n"+w.Model.View()),
+		lipgloss.NewStyle().Align(lipgloss.Center).Render(w.spinner.title("weight per day, "+w.date.String())+"\n"+w.Model.View()+"\n"+w.tooltip.View(w.Model.Width())),
 	)
 }
 
+// SetSize fits the chart, its title and tooltip into width by height.
+func (w *WeightChart) SetSize(width, height int) {
+	// the title above the chart, the tooltip below
+	w.Resize(max(width, 1), max(height-2, 1))
+	w.DrawXYAxisAndLabel()
+	w.DrawBrailleAll()
+}
+
+// NewWeightChart returns an empty chart of the month up to today, its data
+// is loaded by Init.
 func NewWeightChart(db *sql.DB, width, height int) WeightChart {
 	weightChart := tslc.New(width, height)
+	weightChart.SetYRange(150, 170)
+	weightChart.SetViewYRange(150, 170)
+	weightChart.XLabelFormatter = tslc.DateTimeLabelFormatter()
+	weightChart.Canvas.KeyMap = teaKeys.canvasKeyMap()
+
+	return WeightChart{
+		Model:   weightChart,
+		db:      db,
+		date:    todayViewDate(zoomDay),
+		spinner: newLoadingSpinner(),
+	}
+}
+
+// Init starts loading the chart's weights.
+func (w WeightChart) Init() tea.Cmd {
+	return tea.Batch(w.spinner.Tick, w.load())
+}
 
-	now := time.Now()
-	weightChart = LoadWeightChart(db, weightChart, time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()))
-	return WeightChart{weightChart}
+// weightRange returns the days the chart shows for date. A day or week
+// has too few weights for a line, so the month up to it is shown.
+func weightRange(date viewDate) (time.Time, time.Time) {
+	if date.zoom < zoomMonth {
+		end := date.End()
+		return addMonths(end, -1), end
+	}
+	return date.Start(), date.End()
 }
-func LoadWeightChart(db *sql.DB, chart tslc.Model, startDate time.Time) tslc.Model {
+
+func (w WeightChart) load() tea.Cmd {
+	db, date := w.db, w.date
+	return func() tea.Msg {
+		start, end := weightRange(date)
+		return weightDataMsg{date: date, points: GetWeightData(db, start, end)}
+	}
+}
+
+// SetDate shows the weights of date's range.
+func (w *WeightChart) SetDate(date viewDate) tea.Cmd {
+	w.date = date
+	return tea.Batch(w.spinner.start(), w.load())
+}
+
+func (w WeightChart) Update(msg tea.Msg) (WeightChart, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case weightDataMsg:
+		if !msg.date.Equal(w.date) {
+			return w, nil
+		}
+		w.spinner.stop()
+		w.ClearAllData()
+		w.Clear()
+		w.points = msg.points
+		for _, p := range msg.points {
+			w.Push(p)
+		}
+		start, end := weightRange(w.date)
+		w.SetTimeRange(start, end)
+		w.SetViewTimeRange(start, end)
+		w.DrawXYAxisAndLabel()
+	case spinner.TickMsg:
+		w.spinner, cmd = w.spinner.update(msg)
+	case tea.MouseMsg:
+		w.tooltip = tooltip{}
+		if t, x, ok := hoverTime(&w.Model, msg); ok {
+			if p, ok := nearestPoint(w.points, t); ok {
+				w.tooltip = tooltip{x: x, text: fmt.Sprintf("%s  %.1f", p.Time.Format("2006-01-02"), p.Value)}
+			}
+		}
+		if dragging(msg) {
+			w.Model, cmd = w.Model.Update(msg)
+		}
+	default:
+		w.Model, cmd = w.Model.Update(msg)
+	}
+	return w, cmd
+}
+
+// GetWeightData returns the daily weights recorded from startDate up to
+// but not including endDate.
+func GetWeightData(db *sql.DB, startDate, endDate time.Time) []tslc.TimePoint {
 	stmt, err := db.Prepare(
-		`SELECT date(date), weight FROM WeightRecords where date >= ?`,
+		`SELECT date(date), weight FROM WeightRecords where date >= ? AND date < ? ORDER BY date`,
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer stmt.Close()
-	rows, err := stmt.Query(startDate.Format("2006-01-02"))
+	rows, err := stmt.Query(startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 	if err != nil {
-		log.Fatal()
+		log.Fatal(err)
 	}
 	defer rows.Close()
 
-	var weightRecords []float64
-	var weightTimes []string
-
+	var points []tslc.TimePoint
 	for rows.Next() {
 		var wr float64
 		var wt string
@@ -50,34 +147,11 @@ func LoadWeightChart(db *sql.DB, chart tslc.Model, startDate time.Time) tslc.Mod
 		if err != nil {
 			log.Fatal(err)
 		}
-		weightRecords = append(weightRecords, wr)
-		weightTimes = append(weightTimes, wt)
-	}
-
-	minWeight, maxWeight := weightRecords[0], weightRecords[0]
-	for i := range weightTimes {
-		wt, err := time.Parse("2006-01-02", weightTimes[i])
+		t, err := time.Parse("2006-01-02", wt)
 		if err != nil {
 			log.Fatal("error parsing date from db", err)
 		}
-		chart.Push(tslc.TimePoint{Time: wt, Value: weightRecords[i]})
-		if weightRecords[i] < minWeight {
-			minWeight = weightRecords[i]
-		}
-
-		if weightRecords[i] > maxWeight {
-			maxWeight = weightRecords[i]
-		}
-	}
-
-	chart.SetYRange(150, 170)
-	chart.SetViewYRange(150, 170)
-	chart.XLabelFormatter = tslc.DateTimeLabelFormatter()
-	xEndTime, err := time.Parse("2006-01-02", weightTimes[len(weightTimes)-1])
-	if err != nil {
-		log.Fatal(err)
+		points = append(points, tslc.TimePoint{Time: t, Value: wr})
 	}
-	chart.SetViewTimeRange(startDate, xEndTime)
-
-	return chart
+	return points
 }
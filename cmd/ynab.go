@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"io"
+	"log"
+	"net/http"
 	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/haclark30/vitus/ynab"
 	"github.com/spf13/cobra"
@@ -12,13 +15,213 @@ import (
 var ynabCmd = &cobra.Command{
 	Use:   "ynab",
 	Short: "You Need a Budget stats",
-	Run:   ynabRun,
 }
 
-func ynabRun(cmd *cobra.Command, args []string) {
-	ynabClient := ynab.NewYnabClient(os.Getenv("YNAB_API_KEY"))
-	resp, _ := ynabClient.Get("https://api.ynab.com/v1/budgets")
-	defer resp.Body.Close()
-	y, _ := io.ReadAll(resp.Body)
-	fmt.Println(string(y))
+var ynabBudgetsCmd = &cobra.Command{
+	Use:   "budgets",
+	Short: "list budgets",
+	Run:   ynabBudgets,
+}
+
+var ynabAccountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "list accounts and balances",
+	Run:   ynabAccounts,
+}
+
+var ynabCategoriesCmd = &cobra.Command{
+	Use:   "categories",
+	Short: "list categories with this month's budgeted, activity and balance",
+	Run:   ynabCategories,
+}
+
+var ynabTransactionsCmd = &cobra.Command{
+	Use:   "transactions",
+	Short: "list transactions",
+	Run:   ynabTransactions,
+}
+
+var ynabReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "budgeted, activity and available per category group with projected spending",
+	Run:   ynabReport,
+}
+
+var (
+	ynabBudgetId string
+	ynabMonth    string
+	ynabShowAll  bool
+	ynabFrom     string
+	ynabTo       string
+)
+
+func init() {
+	ynabCmd.PersistentFlags().StringVar(&ynabBudgetId, "budget", ynab.LastUsedBudget, "budget id")
+	ynabAccountsCmd.Flags().BoolVar(&ynabShowAll, "all", false, "include closed accounts")
+	ynabCategoriesCmd.Flags().BoolVar(&ynabShowAll, "all", false, "include hidden categories")
+	addDateRangeFlags(ynabTransactionsCmd, &ynabFrom, &ynabTo)
+	ynabReportCmd.Flags().StringVar(&ynabMonth, "month", time.Now().Format("2006-01"), "month to report on, YYYY-MM")
+	ynabCmd.AddCommand(ynabBudgetsCmd)
+	ynabCmd.AddCommand(ynabAccountsCmd)
+	ynabCmd.AddCommand(ynabCategoriesCmd)
+	ynabCmd.AddCommand(ynabTransactionsCmd)
+	ynabCmd.AddCommand(ynabReportCmd)
+}
+
+func newYnabClient() *http.Client {
+	return ynab.NewYnabClient(os.Getenv("YNAB_API_KEY"))
+}
+
+// ynabCurrency returns the currency format of the selected budget.
+func ynabCurrency(client *http.Client) ynab.CurrencyFormat {
+	settings, err := ynab.GetBudgetSettings(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return settings.CurrencyFormat
+}
+
+func newTabWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+}
+
+func ynabBudgets(cmd *cobra.Command, args []string) {
+	budgets, err := ynab.GetBudgets(newYnabClient())
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := newTabWriter()
+	fmt.Fprintln(w, "NAME\tID\tLAST MODIFIED")
+	for _, b := range budgets {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", b.Name, b.Id, b.LastModifiedOn)
+	}
+	w.Flush()
+}
+
+func ynabAccounts(cmd *cobra.Command, args []string) {
+	client := newYnabClient()
+	accounts, err := ynab.GetAccounts(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	currency := ynabCurrency(client)
+
+	w := newTabWriter()
+	fmt.Fprintln(w, "ACCOUNT\tTYPE\tBALANCE\tCLEARED\tUNCLEARED")
+	var total ynab.Milliunits
+	for _, a := range accounts {
+		if a.Deleted || (a.Closed && !ynabShowAll) {
+			continue
+		}
+		name := a.Name
+		if !a.OnBudget {
+			name += " (tracking)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, a.Type,
+			currency.Format(a.Balance), currency.Format(a.ClearedBalance), currency.Format(a.UnclearedBalance))
+		total += a.Balance
+	}
+	fmt.Fprintf(w, "TOTAL\t\t%s\t\t\n", currency.Format(total))
+	w.Flush()
+}
+
+func ynabCategories(cmd *cobra.Command, args []string) {
+	client := newYnabClient()
+	groups, err := ynab.GetCategories(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	currency := ynabCurrency(client)
+
+	w := newTabWriter()
+	fmt.Fprintln(w, "CATEGORY\tBUDGETED\tACTIVITY\tAVAILABLE")
+	for _, g := range groups {
+		if g.Deleted || (g.Hidden && !ynabShowAll) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t\t\t\n", g.Name)
+		for _, c := range g.Categories {
+			if c.Deleted || (c.Hidden && !ynabShowAll) {
+				continue
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", c.Name,
+				currency.Format(c.Budgeted), currency.Format(c.Activity), currency.Format(c.Balance))
+		}
+	}
+	w.Flush()
+}
+
+func ynabTransactions(cmd *cobra.Command, args []string) {
+	client := newYnabClient()
+	dates := parseDateRange(ynabFrom, ynabTo, "-30d", time.Time{})
+	transactions, err := ynab.GetTransactions(client, ynabBudgetId, dates.Start)
+	if err != nil {
+		log.Fatal(err)
+	}
+	currency := ynabCurrency(client)
+
+	w := newTabWriter()
+	fmt.Fprintln(w, "DATE\tACCOUNT\tPAYEE\tCATEGORY\tAMOUNT\tMEMO")
+	for _, t := range transactions {
+		if t.Deleted || t.Date > dates.End.Format("2006-01-02") {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", t.Date, t.AccountName, t.PayeeName,
+			t.CategoryName, currency.Format(t.Amount), t.Memo)
+		for _, s := range t.Subtransactions {
+			if s.Deleted {
+				continue
+			}
+			fmt.Fprintf(w, "\t\t  %s\t%s\t%s\t%s\n", s.PayeeName, s.CategoryName,
+				currency.Format(s.Amount), s.Memo)
+		}
+	}
+	w.Flush()
+}
+
+func ynabReport(cmd *cobra.Command, args []string) {
+	month, err := time.ParseInLocation("2006-01", ynabMonth, time.Local)
+	if err != nil {
+		log.Fatalf("not a valid month: %s", ynabMonth)
+	}
+	client := newYnabClient()
+	groups, err := ynab.GetCategories(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	budgetMonth, err := ynab.GetMonth(client, ynabBudgetId, month)
+	if err != nil {
+		log.Fatal(err)
+	}
+	currency := ynabCurrency(client)
+	now := time.Now()
+
+	w := newTabWriter()
+	fmt.Fprintln(w, "CATEGORY\tBUDGETED\tACTIVITY\tAVAILABLE\tPROJECTED\t")
+	var total categoryReport
+	for _, g := range buildBudgetReport(groups, budgetMonth) {
+		gt := g.Totals()
+		total.Budgeted += gt.Budgeted
+		total.Activity += gt.Activity
+		total.Balance += gt.Balance
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", g.Name, currency.Format(gt.Budgeted),
+			currency.Format(gt.Activity), currency.Format(gt.Balance),
+			currency.Format(projectSpend(gt.Spent(), month, now)))
+		for _, c := range g.Categories {
+			projected := projectSpend(c.Spent(), month, now)
+			status := ""
+			switch {
+			case c.Overspent():
+				status = overspentStyle.Render("overspent")
+			case c.overPace(projected):
+				status = overPaceStyle.Render("over pace")
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", c.Name, currency.Format(c.Budgeted),
+				currency.Format(c.Activity), currency.Format(c.Balance), currency.Format(projected), status)
+		}
+	}
+	fmt.Fprintf(w, "TOTAL\t%s\t%s\t%s\t%s\t\n", currency.Format(total.Budgeted),
+		currency.Format(total.Activity), currency.Format(total.Balance),
+		currency.Format(projectSpend(total.Spent(), month, now)))
+	w.Flush()
 }
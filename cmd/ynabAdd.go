@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haclark30/vitus/daterange"
+	"github.com/haclark30/vitus/ynab"
+	"github.com/spf13/cobra"
+)
+
+var ynabAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add a transaction",
+	Long: `Add a transaction. Amounts are negative for outflows.
+
+A split transaction is added with one --split per line in the form
+category=amount[=memo], where --amount defaults to the total of the splits.
+Transactions with an --import-id already in the account are not added again.`,
+	Run: ynabAdd,
+}
+
+var (
+	ynabAddAccount  string
+	ynabAddPayee    string
+	ynabAddCategory string
+	ynabAddAmount   string
+	ynabAddMemo     string
+	ynabAddDate     string
+	ynabAddCleared  bool
+	ynabAddApproved bool
+	ynabAddSplits   []string
+	ynabAddImportId string
+)
+
+func init() {
+	ynabAddCmd.Flags().StringVar(&ynabAddAccount, "account", "", "account name or id")
+	ynabAddCmd.Flags().StringVar(&ynabAddPayee, "payee", "", "payee name")
+	ynabAddCmd.Flags().StringVar(&ynabAddCategory, "category", "", "category name, or group:category")
+	ynabAddCmd.Flags().StringVar(&ynabAddAmount, "amount", "", "amount, negative for outflows")
+	ynabAddCmd.Flags().StringVar(&ynabAddMemo, "memo", "", "memo")
+	ynabAddCmd.Flags().StringVar(&ynabAddDate, "date", "today", "date of the transaction")
+	ynabAddCmd.Flags().BoolVar(&ynabAddCleared, "cleared", false, "mark the transaction cleared")
+	ynabAddCmd.Flags().BoolVar(&ynabAddApproved, "approved", true, "mark the transaction approved")
+	ynabAddCmd.Flags().StringArrayVar(&ynabAddSplits, "split", nil, "split line as category=amount[=memo], repeatable")
+	ynabAddCmd.Flags().StringVar(&ynabAddImportId, "import-id", "", "import id to avoid adding the transaction twice")
+	ynabAddCmd.MarkFlagRequired("account")
+	ynabCmd.AddCommand(ynabAddCmd)
+}
+
+func ynabAdd(cmd *cobra.Command, args []string) {
+	client := newYnabClient()
+	accounts, err := ynab.GetAccounts(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	groups, err := ynab.GetCategories(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	account, err := findAccount(accounts, ynabAddAccount)
+	if err != nil {
+		log.Fatal(err)
+	}
+	date, err := daterange.Parse(ynabAddDate, time.Now())
+	if err != nil || date.Start.IsZero() {
+		log.Fatalf("not a valid date: %s", ynabAddDate)
+	}
+
+	t := ynab.SaveTransaction{
+		AccountId: account.Id,
+		Date:      date.Start.Format("2006-01-02"),
+		PayeeName: ynabAddPayee,
+		Memo:      ynabAddMemo,
+		Approved:  ynabAddApproved,
+		ImportId:  ynabAddImportId,
+	}
+	if ynabAddCleared {
+		t.Cleared = "cleared"
+	}
+	if ynabAddCategory != "" {
+		category, err := findCategory(groups, ynabAddCategory)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t.CategoryId = category.Id
+	}
+
+	var splitTotal ynab.Milliunits
+	for _, s := range ynabAddSplits {
+		parts := strings.SplitN(s, "=", 3)
+		if len(parts) < 2 {
+			log.Fatalf("split %q is not category=amount[=memo]", s)
+		}
+		category, err := findCategory(groups, parts[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		amount, err := parseAmount(parts[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		sub := ynab.SaveSubtransaction{Amount: amount, CategoryId: category.Id}
+		if len(parts) == 3 {
+			sub.Memo = parts[2]
+		}
+		t.Subtransactions = append(t.Subtransactions, sub)
+		splitTotal += amount
+	}
+
+	switch {
+	case ynabAddAmount != "":
+		t.Amount, err = parseAmount(ynabAddAmount)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(t.Subtransactions) > 0 && t.Amount != splitTotal {
+			log.Fatalf("splits total %s but amount is %s", splitTotal, t.Amount)
+		}
+	case len(t.Subtransactions) > 0:
+		t.Amount = splitTotal
+	default:
+		log.Fatal("--amount or --split is required")
+	}
+	if len(t.Subtransactions) > 0 && t.CategoryId != "" {
+		log.Fatal("--category can't be used with --split")
+	}
+
+	result, err := ynab.CreateTransactions(client, ynabBudgetId, []ynab.SaveTransaction{t})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(result.DuplicateImportIds) > 0 {
+		fmt.Printf("transaction with import id %s already exists\n", t.ImportId)
+		return
+	}
+	for _, created := range result.Transactions {
+		fmt.Printf("added %s %s %s %s\n", created.Date, created.PayeeName, created.Amount, created.Id)
+	}
+}
+
+// parseAmount parses a decimal amount in currency units.
+func parseAmount(s string) (ynab.Milliunits, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid amount: %s", s)
+	}
+	return ynab.FromFloat64(f), nil
+}
+
+// findAccount finds an open account by id or case insensitive name.
+func findAccount(accounts []ynab.Account, nameOrId string) (ynab.Account, error) {
+	for _, a := range accounts {
+		if a.Deleted || a.Closed {
+			continue
+		}
+		if a.Id == nameOrId || strings.EqualFold(a.Name, nameOrId) {
+			return a, nil
+		}
+	}
+	return ynab.Account{}, fmt.Errorf("no open account %q", nameOrId)
+}
+
+// findCategory finds a category by id or case insensitive name. A name of
+// the form group:category picks between categories with the same name.
+// Names are matched whole first, so a category with a colon in its name
+// can be given as is, and otherwise split at each colon in turn so either
+// name may hold one.
+func findCategory(groups []ynab.CategoryGroup, name string) (ynab.Category, error) {
+	match := func(groupName, categoryName string, anyGroup bool) []ynab.Category {
+		var found []ynab.Category
+		for _, g := range groups {
+			if g.Deleted || (!anyGroup && !strings.EqualFold(strings.TrimSpace(groupName), g.Name)) {
+				continue
+			}
+			for _, c := range g.Categories {
+				if c.Deleted {
+					continue
+				}
+				if (anyGroup && c.Id == name) || strings.EqualFold(c.Name, strings.TrimSpace(categoryName)) {
+					found = append(found, c)
+				}
+			}
+		}
+		return found
+	}
+	found := match("", name, true)
+	if len(found) == 0 {
+		for i := range name {
+			if name[i] == ':' {
+				found = append(found, match(name[:i], name[i+1:], false)...)
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return ynab.Category{}, fmt.Errorf("no category %q", name)
+	case 1:
+		return found[0], nil
+	default:
+		return ynab.Category{}, fmt.Errorf("more than one category %q, use group:category", name)
+	}
+}
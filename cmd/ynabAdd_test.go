@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/haclark30/vitus/ynab"
+)
+
+func TestFindCategory(t *testing.T) {
+	groups := []ynab.CategoryGroup{
+		{Name: "Bills", Categories: []ynab.Category{
+			{Id: "rent", Name: "Rent"},
+			{Id: "bills-misc", Name: "Misc"},
+			{Id: "tv", Name: "TV: Streaming"},
+		}},
+		{Name: "Fun", Categories: []ynab.Category{
+			{Id: "fun-misc", Name: "Misc"},
+			{Id: "old", Name: "Old", Deleted: true},
+		}},
+		{Name: "Savings: Long Term", Categories: []ynab.Category{
+			{Id: "house", Name: "House"},
+			{Id: "car", Name: "Car: Next"},
+		}},
+		{Name: "Gone", Deleted: true, Categories: []ynab.Category{
+			{Id: "gone", Name: "Gone"},
+		}},
+	}
+	tests := []struct {
+		name string
+		want string // id, empty for an error
+	}{
+		{"rent", "rent"},
+		{"Rent", "rent"},
+		{"bills:rent", "rent"},
+		{"Bills: Rent", "rent"},
+		{"Misc", ""},
+		{"Fun:Misc", "fun-misc"},
+		{"Bills:Misc", "bills-misc"},
+		{"fun-misc", "fun-misc"},
+		// colons in category names
+		{"TV: Streaming", "tv"},
+		{"Bills:TV: Streaming", "tv"},
+		// and in group names
+		{"Savings: Long Term:House", "house"},
+		{"Savings: Long Term:Car: Next", "car"},
+		{"Car: Next", "car"},
+		{"Fun:Rent", ""},
+		{"Old", ""},
+		{"Gone", ""},
+		{"Nothing", ""},
+	}
+	for _, tt := range tests {
+		got, err := findCategory(groups, tt.name)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("findCategory(%q) = %s, want an error", tt.name, got.Id)
+		case tt.want != "" && err != nil:
+			t.Errorf("findCategory(%q) error: %v", tt.name, err)
+		case got.Id != tt.want:
+			t.Errorf("findCategory(%q) = %s, want %s", tt.name, got.Id, tt.want)
+		}
+	}
+}
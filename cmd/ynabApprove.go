@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haclark30/vitus/ynab"
+	"github.com/spf13/cobra"
+)
+
+var ynabApproveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "categorise and approve unapproved transactions",
+	Run:   ynabApprove,
+}
+
+func init() {
+	ynabCmd.AddCommand(ynabApproveCmd)
+}
+
+// approveItem is an unapproved transaction with the category picked for it.
+type approveItem struct {
+	t            ynab.Transaction
+	currency     ynab.CurrencyFormat
+	categoryId   string
+	categoryName string
+	marked       bool
+}
+
+func (i approveItem) Title() string {
+	mark := "[ ]"
+	if i.marked {
+		mark = "[x]"
+	}
+	return fmt.Sprintf("%s %s  %s  %s", mark, i.t.Date, i.t.PayeeName, i.currency.Format(i.t.Amount))
+}
+
+func (i approveItem) Description() string {
+	category := i.categoryName
+	if len(i.t.Subtransactions) > 0 {
+		category = "Split"
+	} else if category == "" {
+		category = "uncategorised"
+	}
+	desc := "    " + i.t.AccountName + " · " + category
+	if i.t.Memo != "" {
+		desc += " · " + i.t.Memo
+	}
+	return desc
+}
+
+func (i approveItem) FilterValue() string {
+	return i.t.PayeeName + " " + i.t.Memo
+}
+
+type categoryItem struct {
+	group    string
+	category ynab.Category
+}
+
+func (i categoryItem) Title() string       { return i.category.Name }
+func (i categoryItem) Description() string { return i.group }
+func (i categoryItem) FilterValue() string { return i.group + " " + i.category.Name }
+
+type approveKeyMap struct {
+	category key.Binding
+	mark     key.Binding
+	markAll  key.Binding
+	submit   key.Binding
+	cancel   key.Binding
+}
+
+var approveKeys = approveKeyMap{
+	category: key.NewBinding(key.WithKeys("enter", "c"), key.WithHelp("enter", "set category")),
+	mark:     key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "mark")),
+	markAll:  key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "mark all")),
+	submit:   key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "approve marked")),
+	cancel:   key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+// approvedMsg is sent when approving transactions has finished.
+type approvedMsg struct {
+	ids []string
+	err error
+}
+
+type approveModel struct {
+	client     *http.Client
+	budgetId   string
+	list       list.Model
+	categories list.Model
+	picking    bool
+	status     string
+}
+
+func newApproveModel(client *http.Client, budgetId string, transactions []ynab.Transaction, groups []ynab.CategoryGroup, currency ynab.CurrencyFormat) approveModel {
+	var items []list.Item
+	for _, t := range transactions {
+		if t.Deleted {
+			continue
+		}
+		items = append(items, approveItem{
+			t:            t,
+			currency:     currency,
+			categoryId:   t.CategoryId,
+			categoryName: t.CategoryName,
+		})
+	}
+	var categories []list.Item
+	for _, g := range groups {
+		if g.Hidden || g.Deleted {
+			continue
+		}
+		for _, c := range g.Categories {
+			if c.Hidden || c.Deleted {
+				continue
+			}
+			categories = append(categories, categoryItem{group: g.Name, category: c})
+		}
+	}
+
+	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "unapproved transactions"
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{approveKeys.category, approveKeys.mark, approveKeys.submit}
+	}
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{approveKeys.category, approveKeys.mark, approveKeys.markAll, approveKeys.submit}
+	}
+
+	c := list.New(categories, list.NewDefaultDelegate(), 0, 0)
+	c.Title = "category"
+	c.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{approveKeys.cancel}
+	}
+
+	return approveModel{
+		client:     client,
+		budgetId:   budgetId,
+		list:       l,
+		categories: c,
+	}
+}
+
+func (m approveModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m approveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v-1)
+		m.categories.SetSize(msg.Width-h, msg.Height-v)
+		return m, nil
+	case approvedMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		approved := make(map[string]bool)
+		for _, id := range msg.ids {
+			approved[id] = true
+		}
+		for i := len(m.list.Items()) - 1; i >= 0; i-- {
+			if approved[m.list.Items()[i].(approveItem).t.Id] {
+				m.list.RemoveItem(i)
+			}
+		}
+		m.status = fmt.Sprintf("approved %d transactions", len(msg.ids))
+		return m, nil
+	case tea.KeyMsg:
+		if m.picking {
+			if m.categories.FilterState() == list.Filtering {
+				break
+			}
+			switch {
+			case key.Matches(msg, approveKeys.cancel):
+				m.picking = false
+				return m, nil
+			case msg.String() == "enter":
+				selected, ok := m.categories.SelectedItem().(categoryItem)
+				if ok {
+					item := m.list.SelectedItem().(approveItem)
+					item.categoryId = selected.category.Id
+					item.categoryName = selected.category.Name
+					item.marked = true
+					cmd = m.list.SetItem(m.list.Index(), item)
+				}
+				m.picking = false
+				m.categories.ResetFilter()
+				return m, cmd
+			}
+			break
+		}
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		switch {
+		case key.Matches(msg, approveKeys.category):
+			if item, ok := m.list.SelectedItem().(approveItem); ok && len(item.t.Subtransactions) == 0 {
+				m.picking = true
+			}
+			return m, nil
+		case key.Matches(msg, approveKeys.mark):
+			if item, ok := m.list.SelectedItem().(approveItem); ok {
+				item.marked = !item.marked
+				cmd = m.list.SetItem(m.list.Index(), item)
+			}
+			return m, cmd
+		case key.Matches(msg, approveKeys.markAll):
+			var cmds []tea.Cmd
+			for i, it := range m.list.Items() {
+				item := it.(approveItem)
+				item.marked = true
+				cmds = append(cmds, m.list.SetItem(i, item))
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, approveKeys.submit):
+			m.status = "approving..."
+			return m, m.approveMarked()
+		}
+	}
+
+	if m.picking {
+		m.categories, cmd = m.categories.Update(msg)
+	} else {
+		m.list, cmd = m.list.Update(msg)
+	}
+	return m, cmd
+}
+
+// approveMarked approves the marked transactions with their picked
+// categories in a single request.
+func (m approveModel) approveMarked() tea.Cmd {
+	var save []ynab.SaveTransaction
+	for _, it := range m.list.Items() {
+		item := it.(approveItem)
+		if !item.marked {
+			continue
+		}
+		t := item.t.SaveTransaction()
+		if len(item.t.Subtransactions) == 0 {
+			t.CategoryId = item.categoryId
+		}
+		t.Approved = true
+		save = append(save, t)
+	}
+	client, budgetId := m.client, m.budgetId
+	return func() tea.Msg {
+		if len(save) == 0 {
+			return approvedMsg{}
+		}
+		result, err := ynab.UpdateTransactions(client, budgetId, save)
+		if err != nil {
+			return approvedMsg{err: err}
+		}
+		return approvedMsg{ids: result.TransactionIds}
+	}
+}
+
+func (m approveModel) View() string {
+	view := m.list.View()
+	if m.picking {
+		view = m.categories.View()
+	}
+	if m.status != "" {
+		view = lipgloss.JoinVertical(lipgloss.Left, view, m.status)
+	}
+	return docStyle.Render(view)
+}
+
+func ynabApprove(cmd *cobra.Command, args []string) {
+	client := newYnabClient()
+	transactions, err := ynab.GetUnapprovedTransactions(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(transactions) == 0 {
+		fmt.Println("no unapproved transactions")
+		return
+	}
+	groups, err := ynab.GetCategories(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m := newApproveModel(client, ynabBudgetId, transactions, groups, ynabCurrency(client))
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		log.Fatal(err)
+	}
+}
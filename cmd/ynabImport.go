@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/haclark30/vitus/bankfile"
+	"github.com/haclark30/vitus/ynab"
+	"github.com/spf13/cobra"
+)
+
+var ynabImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "import transactions from a bank CSV, OFX or QFX file",
+	Long: `Import transactions from a bank CSV, OFX or QFX file into an account.
+
+CSV columns are picked with the --*-col flags, by header name or by 1-based
+number. Payee rules are read from a JSON file given by --rules or
+YNAB_IMPORT_RULES, a list of rules like
+
+	[{"match": "^AMZN", "payee": "Amazon", "category": "Shopping"}]
+
+where match is a case insensitive regular expression on the bank's payee.
+The first matching rule renames the payee and, if it has one, sets the
+category. OFX transactions are given import ids from the bank's FITID and
+CSV ones ids in the format YNAB uses for file imports, so importing the
+same transactions twice adds nothing new.`,
+	Args: cobra.ExactArgs(1),
+	Run:  ynabImport,
+}
+
+var (
+	ynabImportAccount string
+	ynabImportFormat  string
+	ynabImportRules   string
+	ynabImportYes     bool
+	ynabImportMapping bankfile.CSVMapping
+	ynabImportDateFmt string
+	ynabImportDelim   string
+)
+
+func init() {
+	f := ynabImportCmd.Flags()
+	f.StringVar(&ynabImportAccount, "account", "", "account name or id")
+	f.StringVar(&ynabImportFormat, "format", "", "csv or ofx, by default from the file extension")
+	f.StringVar(&ynabImportRules, "rules", os.Getenv("YNAB_IMPORT_RULES"), "JSON file of payee rules")
+	f.BoolVarP(&ynabImportYes, "yes", "y", false, "import without asking after the preview")
+	f.StringVar(&ynabImportMapping.Date, "date-col", "Date", "CSV date column")
+	f.StringVar(&ynabImportMapping.Payee, "payee-col", "Description", "CSV payee column")
+	f.StringVar(&ynabImportMapping.Memo, "memo-col", "", "CSV memo column")
+	f.StringVar(&ynabImportMapping.Amount, "amount-col", "Amount", "CSV amount column")
+	f.StringVar(&ynabImportMapping.Inflow, "inflow-col", "", "CSV inflow column, used with --outflow-col instead of --amount-col")
+	f.StringVar(&ynabImportMapping.Outflow, "outflow-col", "", "CSV outflow column")
+	f.StringVar(&ynabImportDateFmt, "date-format", "", "CSV date format as a Go layout, e.g. 02/01/2006")
+	f.StringVar(&ynabImportDelim, "delimiter", ",", "CSV field delimiter")
+	f.BoolVar(&ynabImportMapping.Negate, "negate", false, "flip the sign of CSV amounts")
+	f.BoolVar(&ynabImportMapping.NoHeader, "no-header", false, "CSV has no header row, columns are numbers")
+	ynabImportCmd.MarkFlagRequired("account")
+	ynabCmd.AddCommand(ynabImportCmd)
+}
+
+// payeeRule renames payees matching Match and optionally categorises them.
+type payeeRule struct {
+	Match    string `json:"match"`
+	Payee    string `json:"payee"`
+	Category string `json:"category"`
+
+	re         *regexp.Regexp
+	categoryId string
+}
+
+// loadPayeeRules reads rules from path, resolving their categories.
+func loadPayeeRules(path string, groups []ynab.CategoryGroup) []payeeRule {
+	if path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var rules []payeeRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		log.Fatalf("reading %s: %v", path, err)
+	}
+	for i := range rules {
+		rules[i].re, err = regexp.Compile("(?i)" + rules[i].Match)
+		if err != nil {
+			log.Fatalf("rule %d in %s: %v", i+1, path, err)
+		}
+		if rules[i].Category != "" {
+			category, err := findCategory(groups, rules[i].Category)
+			if err != nil {
+				log.Fatalf("rule %d in %s: %v", i+1, path, err)
+			}
+			rules[i].categoryId = category.Id
+		}
+	}
+	return rules
+}
+
+// applyPayeeRules returns the payee and category id for a bank payee.
+func applyPayeeRules(rules []payeeRule, payee string) (string, string) {
+	for _, r := range rules {
+		if r.re.MatchString(payee) {
+			if r.Payee != "" {
+				payee = r.Payee
+			}
+			return payee, r.categoryId
+		}
+	}
+	return payee, ""
+}
+
+// maxImportId is the longest import id YNAB accepts.
+const maxImportId = 36
+
+// importIds returns an import id for each transaction. Transactions with
+// the bank's own id, the FITID of OFX files, get OFX:id, so the same
+// transaction gets the same id in overlapping exports. Others get the
+// format YNAB uses for file imports, YNAB:amount:date:occurrence, where
+// occurrence counts transactions with the same amount and date in the file.
+func importIds(transactions []bankfile.Transaction) []string {
+	seen := make(map[string]int)
+	ids := make([]string, len(transactions))
+	for i, t := range transactions {
+		if t.Id != "" {
+			// long ids usually share a prefix and differ at the end, so
+			// keep the end
+			id := []rune(t.Id)
+			if n := maxImportId - len("OFX:"); len(id) > n {
+				id = id[len(id)-n:]
+			}
+			ids[i] = "OFX:" + string(id)
+			// a FITID repeated within the file gets the YNAB format instead
+			seen[ids[i]]++
+			if seen[ids[i]] == 1 {
+				continue
+			}
+		}
+		key := fmt.Sprintf("%d:%s", int64(t.Amount), t.Date.Format("2006-01-02"))
+		seen[key]++
+		ids[i] = fmt.Sprintf("YNAB:%s:%d", key, seen[key])
+	}
+	return ids
+}
+
+func readBankFile(path string) []bankfile.Transaction {
+	format := strings.ToLower(ynabImportFormat)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	var transactions []bankfile.Transaction
+	switch format {
+	case "csv":
+		if ynabImportDateFmt != "" {
+			ynabImportMapping.DateFormats = []string{ynabImportDateFmt}
+		}
+		if delim := []rune(ynabImportDelim); len(delim) == 1 {
+			ynabImportMapping.Delimiter = delim[0]
+		} else if ynabImportDelim == `\t` {
+			ynabImportMapping.Delimiter = '\t'
+		}
+		transactions, err = bankfile.ReadCSV(file, ynabImportMapping)
+	case "ofx", "qfx":
+		transactions, err = bankfile.ReadOFX(file)
+	default:
+		log.Fatalf("unknown file format %q, use --format csv or --format ofx", format)
+	}
+	if err != nil {
+		log.Fatalf("reading %s: %v", path, err)
+	}
+	return transactions
+}
+
+func ynabImport(cmd *cobra.Command, args []string) {
+	transactions := readBankFile(args[0])
+	if len(transactions) == 0 {
+		fmt.Println("no transactions in", args[0])
+		return
+	}
+
+	client := newYnabClient()
+	accounts, err := ynab.GetAccounts(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	account, err := findAccount(accounts, ynabImportAccount)
+	if err != nil {
+		log.Fatal(err)
+	}
+	groups, err := ynab.GetCategories(client, ynabBudgetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules := loadPayeeRules(ynabImportRules, groups)
+	currency := ynabCurrency(client)
+
+	categoryNames := make(map[string]string)
+	for _, g := range groups {
+		for _, c := range g.Categories {
+			categoryNames[c.Id] = c.Name
+		}
+	}
+
+	var save []ynab.SaveTransaction
+	w := newTabWriter()
+	fmt.Fprintln(w, "DATE\tPAYEE\tCATEGORY\tAMOUNT\tMEMO\tIMPORT ID")
+	for i, id := range importIds(transactions) {
+		t := transactions[i]
+		payee, categoryId := applyPayeeRules(rules, t.Payee)
+		save = append(save, ynab.SaveTransaction{
+			AccountId:  account.Id,
+			Date:       t.Date.Format("2006-01-02"),
+			Amount:     t.Amount,
+			PayeeName:  truncate(payee, 50),
+			CategoryId: categoryId,
+			Memo:       truncate(t.Memo, 200),
+			Cleared:    "cleared",
+			ImportId:   id,
+		})
+		shownPayee := payee
+		if payee != t.Payee {
+			shownPayee = fmt.Sprintf("%s (%s)", payee, t.Payee)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", t.Date.Format("2006-01-02"), shownPayee,
+			categoryNames[categoryId], currency.Format(t.Amount), t.Memo, id)
+	}
+	w.Flush()
+
+	if !ynabImportYes {
+		fmt.Printf("\nimport %d transactions into %s? [y/N] ", len(save), account.Name)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("nothing imported")
+			return
+		}
+	}
+
+	result, err := ynab.BulkCreateTransactions(client, ynabBudgetId, save)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("imported %d transactions, %d already imported\n",
+		len(result.TransactionIds), len(result.DuplicateImportIds))
+}
+
+// truncate shortens s to the n characters YNAB accepts for a field.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
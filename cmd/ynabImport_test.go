@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haclark30/vitus/bankfile"
+)
+
+func TestImportIds(t *testing.T) {
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)
+	transactions := []bankfile.Transaction{
+		{Date: day, Amount: -4500},
+		{Date: day, Amount: -4500},
+		{Date: day, Amount: -4500, Id: "202403150001"},
+		{Date: day, Amount: 1000, Id: "2024031500000000000000000000000000000042"},
+		{Date: day, Amount: -4500, Id: "202403150001"},
+		{Date: day.AddDate(0, 0, 1), Amount: -4500},
+	}
+	want := []string{
+		"YNAB:-4500:2024-03-15:1",
+		"YNAB:-4500:2024-03-15:2",
+		"OFX:202403150001",
+		"OFX:00000000000000000000000000000042",
+		"YNAB:-4500:2024-03-15:3",
+		"YNAB:-4500:2024-03-16:1",
+	}
+	got := importIds(transactions)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("importIds =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, id := range got {
+		if len(id) > maxImportId {
+			t.Errorf("import id %s is longer than %d", id, maxImportId)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/haclark30/vitus/ynab"
+)
+
+// number of past months whose per category balances are fetched
+// when the months endpoint reports them as changed
+const ynabMonthHistory = 12
+
+// ynabDelta holds everything that changed in a budget since the last sync.
+type ynabDelta struct {
+	accounts     []ynab.Account
+	groups       []ynab.CategoryGroup
+	payees       []ynab.Payee
+	months       []ynab.Month
+	transactions []ynab.Transaction
+	knowledge    map[string]int64
+}
+
+// syncYnab stores every budget and pulls only what changed in each
+// budget since the server knowledge stored by the previous sync.
+func syncYnab(db *sql.DB, client *http.Client) error {
+	budgets, err := ynab.GetBudgets(client)
+	if err != nil {
+		return err
+	}
+	for _, b := range budgets {
+		currency, err := json.Marshal(b.CurrencyFormat)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(
+			`INSERT OR REPLACE INTO YnabBudgets
+				(id, name, lastModifiedOn, firstMonth, lastMonth, currencyFormat)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			b.Id, b.Name, b.LastModifiedOn, b.FirstMonth, b.LastMonth, string(currency))
+		if err != nil {
+			return err
+		}
+		if err := syncYnabBudget(db, client, b.Id); err != nil {
+			return fmt.Errorf("syncing budget %s: %w", b.Name, err)
+		}
+	}
+	return nil
+}
+
+func syncYnabBudget(db *sql.DB, client *http.Client, budgetId string) error {
+	last, err := ynabKnowledge(db, budgetId)
+	if err != nil {
+		return err
+	}
+	delta, err := fetchYnabDelta(client, budgetId, last)
+	if err != nil {
+		return err
+	}
+	slog.Debug("ynab delta", "budget", budgetId,
+		"accounts", len(delta.accounts),
+		"categoryGroups", len(delta.groups),
+		"payees", len(delta.payees),
+		"months", len(delta.months),
+		"transactions", len(delta.transactions),
+	)
+
+	txn, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+	if err := writeYnabDelta(txn, budgetId, delta); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// ynabKnowledge returns the server knowledge stored for each resource of a budget.
+func ynabKnowledge(db *sql.DB, budgetId string) (map[string]int64, error) {
+	rows, err := db.Query(
+		`SELECT resource, serverKnowledge FROM YnabServerKnowledge WHERE budgetId = ?`, budgetId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	knowledge := make(map[string]int64)
+	for rows.Next() {
+		var resource string
+		var k int64
+		if err := rows.Scan(&resource, &k); err != nil {
+			return nil, err
+		}
+		knowledge[resource] = k
+	}
+	return knowledge, rows.Err()
+}
+
+func fetchYnabDelta(client *http.Client, budgetId string, last map[string]int64) (ynabDelta, error) {
+	delta := ynabDelta{knowledge: make(map[string]int64)}
+	var err error
+
+	delta.accounts, delta.knowledge["accounts"], err = ynab.GetAccountsDelta(client, budgetId, last["accounts"])
+	if err != nil {
+		return delta, err
+	}
+	delta.groups, delta.knowledge["categories"], err = ynab.GetCategoriesDelta(client, budgetId, last["categories"])
+	if err != nil {
+		return delta, err
+	}
+	delta.payees, delta.knowledge["payees"], err = ynab.GetPayeesDelta(client, budgetId, last["payees"])
+	if err != nil {
+		return delta, err
+	}
+	delta.transactions, delta.knowledge["transactions"], err = ynab.GetTransactionsDelta(
+		client, budgetId, time.Time{}, last["transactions"])
+	if err != nil {
+		return delta, err
+	}
+
+	months, knowledge, err := ynab.GetMonthsDelta(client, budgetId, last["months"])
+	if err != nil {
+		return delta, err
+	}
+	delta.knowledge["months"] = knowledge
+	oldest := time.Now().AddDate(0, -ynabMonthHistory, 0).Format("2006-01")
+	for _, m := range months {
+		if m.Deleted || m.Month[:7] < oldest {
+			delta.months = append(delta.months, m)
+			continue
+		}
+		monthStart, err := time.ParseInLocation("2006-01-02", m.Month, time.Local)
+		if err != nil {
+			return delta, err
+		}
+		detail, err := ynab.GetMonth(client, budgetId, monthStart)
+		if err != nil {
+			return delta, err
+		}
+		delta.months = append(delta.months, *detail)
+	}
+	return delta, nil
+}
+
+func writeYnabDelta(txn *sql.Tx, budgetId string, delta ynabDelta) error {
+	for _, a := range delta.accounts {
+		_, err := txn.Exec(
+			`INSERT OR REPLACE INTO YnabAccounts
+				(id, budgetId, name, type, onBudget, closed, balance,
+				clearedBalance, unclearedBalance, transferPayeeId, deleted)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.Id, budgetId, a.Name, a.Type, a.OnBudget, a.Closed, a.Balance,
+			a.ClearedBalance, a.UnclearedBalance, a.TransferPayeeId, a.Deleted)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, g := range delta.groups {
+		_, err := txn.Exec(
+			`INSERT OR REPLACE INTO YnabCategoryGroups (id, budgetId, name, hidden, deleted)
+			VALUES (?, ?, ?, ?, ?)`,
+			g.Id, budgetId, g.Name, g.Hidden, g.Deleted)
+		if err != nil {
+			return err
+		}
+		for _, c := range g.Categories {
+			_, err := txn.Exec(
+				`INSERT OR REPLACE INTO YnabCategories
+					(id, budgetId, categoryGroupId, name, hidden, budgeted,
+					activity, balance, goalType, goalTarget, deleted)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				c.Id, budgetId, c.CategoryGroupId, c.Name, c.Hidden, c.Budgeted,
+				c.Activity, c.Balance, c.GoalType, c.GoalTarget, c.Deleted)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range delta.payees {
+		_, err := txn.Exec(
+			`INSERT OR REPLACE INTO YnabPayees (id, budgetId, name, transferAccountId, deleted)
+			VALUES (?, ?, ?, ?, ?)`,
+			p.Id, budgetId, p.Name, p.TransferAccountId, p.Deleted)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range delta.months {
+		_, err := txn.Exec(
+			`INSERT OR REPLACE INTO YnabMonths
+				(budgetId, month, income, budgeted, activity, toBeBudgeted, ageOfMoney, deleted)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			budgetId, m.Month, m.Income, m.Budgeted, m.Activity, m.ToBeBudgeted, m.AgeOfMoney, m.Deleted)
+		if err != nil {
+			return err
+		}
+		for _, c := range m.Categories {
+			_, err := txn.Exec(
+				`INSERT OR REPLACE INTO YnabMonthCategories
+					(budgetId, month, categoryId, budgeted, activity, balance)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				budgetId, m.Month, c.Id, c.Budgeted, c.Activity, c.Balance)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, t := range delta.transactions {
+		_, err := txn.Exec(
+			`INSERT OR REPLACE INTO YnabTransactions
+				(id, budgetId, date, amount, memo, cleared, approved, flagColor,
+				accountId, payeeId, categoryId, transferAccountId, importId, deleted)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			t.Id, budgetId, t.Date, t.Amount, t.Memo, t.Cleared, t.Approved, t.FlagColor,
+			t.AccountId, t.PayeeId, t.CategoryId, t.TransferAccountId, t.ImportId, t.Deleted)
+		if err != nil {
+			return err
+		}
+		for _, s := range t.Subtransactions {
+			_, err := txn.Exec(
+				`INSERT OR REPLACE INTO YnabSubtransactions
+					(id, transactionId, amount, memo, payeeId, categoryId, transferAccountId, deleted)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				s.Id, t.Id, s.Amount, s.Memo, s.PayeeId, s.CategoryId, s.TransferAccountId, s.Deleted)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for resource, k := range delta.knowledge {
+		_, err := txn.Exec(
+			`INSERT OR REPLACE INTO YnabServerKnowledge (budgetId, resource, serverKnowledge)
+			VALUES (?, ?, ?)`,
+			budgetId, resource, k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Package daterange parses the date range expressions accepted by
+// commands with --from/--to flags.
+//
+// An expression is one of
+//
+//	today, yesterday
+//	2024-03-15             a single day
+//	2024-03                a month
+//	2024                   a year
+//	2024-Q1                a quarter
+//	2024-W05               an ISO week
+//	-30d, -6w, -3m, -1y    from that long ago until today
+//	this-week, last-week, this-month, last-month, this-year, last-year
+//	A..B                   from the start of A to the end of B, either side
+//	                       may be empty for an open ended range
+package daterange
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateFmt = "2006-01-02"
+
+// Range is an inclusive range of days. A zero Start or End is open ended.
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (r Range) String() string {
+	start, end := "", ""
+	if !r.Start.IsZero() {
+		start = r.Start.Format(dateFmt)
+	}
+	if !r.End.IsZero() {
+		end = r.End.Format(dateFmt)
+	}
+	return start + ".." + end
+}
+
+// Days returns the number of days in a closed range.
+func (r Range) Days() int {
+	return int(r.End.Sub(r.Start).Hours()/24+0.5) + 1
+}
+
+var (
+	relativeRe = regexp.MustCompile(`^-(\d+)([dwmy])$`)
+	quarterRe  = regexp.MustCompile(`^(\d{4})-[qQ]([1-4])$`)
+	isoWeekRe  = regexp.MustCompile(`^(\d{4})-[wW](\d{1,2})$`)
+	monthRe    = regexp.MustCompile(`^\d{4}-\d{2}$`)
+	yearRe     = regexp.MustCompile(`^\d{4}$`)
+)
+
+// Parse parses a date range expression relative to now.
+func Parse(expr string, now time.Time) (Range, error) {
+	expr = strings.TrimSpace(expr)
+	if from, to, ok := strings.Cut(expr, ".."); ok {
+		var r Range
+		if from != "" {
+			fromRange, err := Parse(from, now)
+			if err != nil {
+				return Range{}, err
+			}
+			r.Start = fromRange.Start
+		}
+		if to != "" {
+			toRange, err := Parse(to, now)
+			if err != nil {
+				return Range{}, err
+			}
+			r.End = toRange.End
+		}
+		if !r.Start.IsZero() && !r.End.IsZero() && r.Start.After(r.End) {
+			return Range{}, fmt.Errorf("range %q ends before it starts", expr)
+		}
+		return r, nil
+	}
+
+	today := day(now)
+	switch strings.ToLower(expr) {
+	case "":
+		return Range{}, nil
+	case "today":
+		return Range{today, today}, nil
+	case "yesterday":
+		y := today.AddDate(0, 0, -1)
+		return Range{y, y}, nil
+	case "this-week":
+		start := WeekStart(today)
+		return Range{start, start.AddDate(0, 0, 6)}, nil
+	case "last-week":
+		start := WeekStart(today).AddDate(0, 0, -7)
+		return Range{start, start.AddDate(0, 0, 6)}, nil
+	case "this-month":
+		return month(today.Year(), today.Month()), nil
+	case "last-month":
+		return month(today.Year(), today.Month()-1), nil
+	case "this-year":
+		return year(today.Year()), nil
+	case "last-year":
+		return year(today.Year() - 1), nil
+	}
+
+	if m := relativeRe.FindStringSubmatch(expr); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		var start time.Time
+		switch m[2] {
+		case "d":
+			start = today.AddDate(0, 0, -n)
+		case "w":
+			start = today.AddDate(0, 0, -7*n)
+		case "m":
+			start = today.AddDate(0, -n, 0)
+		case "y":
+			start = today.AddDate(-n, 0, 0)
+		}
+		return Range{start, today}, nil
+	}
+	if m := quarterRe.FindStringSubmatch(expr); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		q, _ := strconv.Atoi(m[2])
+		start := time.Date(y, time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, time.Local)
+		return Range{start, start.AddDate(0, 3, -1)}, nil
+	}
+	if m := isoWeekRe.FindStringSubmatch(expr); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		w, _ := strconv.Atoi(m[2])
+		start, err := isoWeekStart(y, w)
+		if err != nil {
+			return Range{}, err
+		}
+		return Range{start, start.AddDate(0, 0, 6)}, nil
+	}
+	if monthRe.MatchString(expr) {
+		t, err := time.ParseInLocation("2006-01", expr, time.Local)
+		if err != nil {
+			return Range{}, fmt.Errorf("not a valid month: %s", expr)
+		}
+		return month(t.Year(), t.Month()), nil
+	}
+	if yearRe.MatchString(expr) {
+		y, _ := strconv.Atoi(expr)
+		return year(y), nil
+	}
+	t, err := time.ParseInLocation(dateFmt, expr, time.Local)
+	if err != nil {
+		return Range{}, fmt.Errorf("not a valid date or range: %s", expr)
+	}
+	return Range{t, t}, nil
+}
+
+// FromTo combines --from and --to flags into a range, using the start of
+// the from expression and the end of the to expression. Either may be
+// empty for an open ended range. A from expression of the form A..B is
+// used as the whole range when to is empty.
+func FromTo(from, to string, now time.Time) (Range, error) {
+	var r Range
+	if from != "" {
+		fromRange, err := Parse(from, now)
+		if err != nil {
+			return Range{}, err
+		}
+		r.Start = fromRange.Start
+		if to == "" && strings.Contains(from, "..") {
+			r.End = fromRange.End
+		}
+	}
+	if to != "" {
+		toRange, err := Parse(to, now)
+		if err != nil {
+			return Range{}, err
+		}
+		r.End = toRange.End
+	}
+	if !r.Start.IsZero() && !r.End.IsZero() && r.Start.After(r.End) {
+		return Range{}, fmt.Errorf("--from %s is after --to %s", from, to)
+	}
+	return r, nil
+}
+
+// Bound closes any open ends of the range with earliest and latest,
+// and checks that the range overlaps them. A zero earliest leaves the
+// start unbounded.
+func (r Range) Bound(earliest, latest time.Time) (Range, error) {
+	earliest, latest = day(earliest), day(latest)
+	if r.Start.IsZero() || (!earliest.IsZero() && r.Start.Before(earliest)) {
+		r.Start = earliest
+	}
+	if r.End.IsZero() || r.End.After(latest) {
+		r.End = latest
+	}
+	if r.Start.IsZero() {
+		return Range{}, fmt.Errorf("range needs a start date")
+	}
+	if r.Start.After(r.End) {
+		return Range{}, fmt.Errorf("no data available for %s, data starts %s and ends %s",
+			r, earliest.Format(dateFmt), latest.Format(dateFmt))
+	}
+	return r, nil
+}
+
+func day(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// WeekStart returns the start of the Monday of the week containing t.
+func WeekStart(t time.Time) time.Time {
+	t = day(t)
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+func month(y int, m time.Month) Range {
+	start := time.Date(y, m, 1, 0, 0, 0, 0, time.Local)
+	return Range{start, start.AddDate(0, 1, -1)}
+}
+
+func year(y int) Range {
+	return Range{
+		time.Date(y, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(y, 12, 31, 0, 0, 0, 0, time.Local),
+	}
+}
+
+// isoWeekStart returns the Monday of ISO week w of year y.
+func isoWeekStart(y, w int) (time.Time, error) {
+	// January 4th is always in week 1
+	start := WeekStart(time.Date(y, 1, 4, 0, 0, 0, 0, time.Local)).AddDate(0, 0, 7*(w-1))
+	if gotY, gotW := start.ISOWeek(); w < 1 || gotY != y || gotW != w {
+		return time.Time{}, fmt.Errorf("%d has no week %d", y, w)
+	}
+	return start, nil
+}
@@ -0,0 +1,211 @@
+package daterange
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func TestParse(t *testing.T) {
+	// a Friday
+	friday := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	// a Wednesday in the ISO week that starts in the year before
+	newYear := time.Date(2025, 1, 1, 8, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		expr string
+		now  time.Time
+		want string
+	}{
+		// days, months and years
+		{"", friday, ".."},
+		{"today", friday, "2024-03-15..2024-03-15"},
+		{"Today", friday, "2024-03-15..2024-03-15"},
+		{"yesterday", friday, "2024-03-14..2024-03-14"},
+		{"yesterday", newYear, "2024-12-31..2024-12-31"},
+		{"2024-03-15", friday, "2024-03-15..2024-03-15"},
+		{" 2024-03-15 ", friday, "2024-03-15..2024-03-15"},
+		{"2024-02", friday, "2024-02-01..2024-02-29"},
+		{"2023-02", friday, "2023-02-01..2023-02-28"},
+		{"2024-12", friday, "2024-12-01..2024-12-31"},
+		{"2024", friday, "2024-01-01..2024-12-31"},
+
+		// named ranges
+		{"this-week", friday, "2024-03-11..2024-03-17"},
+		{"last-week", friday, "2024-03-04..2024-03-10"},
+		{"this-week", newYear, "2024-12-30..2025-01-05"},
+		{"last-week", newYear, "2024-12-23..2024-12-29"},
+		{"this-month", friday, "2024-03-01..2024-03-31"},
+		{"last-month", friday, "2024-02-01..2024-02-29"},
+		{"last-month", newYear, "2024-12-01..2024-12-31"},
+		{"this-year", friday, "2024-01-01..2024-12-31"},
+		{"last-year", newYear, "2024-01-01..2024-12-31"},
+
+		// relative offsets run up to today
+		{"-0d", friday, "2024-03-15..2024-03-15"},
+		{"-30d", friday, "2024-02-14..2024-03-15"},
+		{"-2w", friday, "2024-03-01..2024-03-15"},
+		{"-1m", friday, "2024-02-15..2024-03-15"},
+		{"-1y", friday, "2023-03-15..2024-03-15"},
+		{"-1d", newYear, "2024-12-31..2025-01-01"},
+
+		// quarters
+		{"2024-Q1", friday, "2024-01-01..2024-03-31"},
+		{"2024-q2", friday, "2024-04-01..2024-06-30"},
+		{"2024-Q3", friday, "2024-07-01..2024-09-30"},
+		{"2024-Q4", friday, "2024-10-01..2024-12-31"},
+		{"2023-Q1", friday, "2023-01-01..2023-03-31"},
+
+		// ISO weeks, whose week 1 holds January 4th
+		{"2024-W01", friday, "2024-01-01..2024-01-07"},
+		{"2024-w1", friday, "2024-01-01..2024-01-07"},
+		{"2024-W11", friday, "2024-03-11..2024-03-17"},
+		{"2024-W52", friday, "2024-12-23..2024-12-29"},
+		{"2025-W01", friday, "2024-12-30..2025-01-05"},
+		{"2015-W01", friday, "2014-12-29..2015-01-04"},
+		{"2021-W01", friday, "2021-01-04..2021-01-10"},
+		{"2020-W53", friday, "2020-12-28..2021-01-03"},
+		{"2026-W53", friday, "2026-12-28..2027-01-03"},
+
+		// ranges, open ended on either side
+		{"2024-01..2024-03", friday, "2024-01-01..2024-03-31"},
+		{"2024-W01..2024-Q1", friday, "2024-01-01..2024-03-31"},
+		{"-7d..yesterday", friday, "2024-03-08..2024-03-14"},
+		{"2024-03..", friday, "2024-03-01.."},
+		{"..2024-03", friday, "..2024-03-31"},
+		{"..", friday, ".."},
+		{"2024-03-15..2024-03-15", friday, "2024-03-15..2024-03-15"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.expr, tt.now)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q, %s) = %s, want %s", tt.expr, tt.now.Format(dateFmt), got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	for _, expr := range []string{
+		"tomorrow",
+		"2024-13",
+		"2024-02-30",
+		"2024-Q0",
+		"2024-Q5",
+		"2024-W00",
+		"2024-W53",
+		"2021-W53",
+		"2020-W54",
+		"-3x",
+		"2024-03..2024-02",
+		"2024-03..nonsense",
+		"nonsense..2024-03",
+	} {
+		if got, err := Parse(expr, now); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", expr, got)
+		}
+	}
+}
+
+func TestIsoWeekStart(t *testing.T) {
+	for y := 2000; y <= 2040; y++ {
+		for w := 1; w <= 53; w++ {
+			start, err := isoWeekStart(y, w)
+			if err != nil {
+				// only years whose last week holds Dec 28 into week 53
+				if _, last := date(y, 12, 28).ISOWeek(); w <= last {
+					t.Errorf("isoWeekStart(%d, %d) error: %v", y, w, err)
+				}
+				continue
+			}
+			if start.Weekday() != time.Monday {
+				t.Errorf("isoWeekStart(%d, %d) = %s, a %s", y, w, start.Format(dateFmt), start.Weekday())
+			}
+			if gotY, gotW := start.ISOWeek(); gotY != y || gotW != w {
+				t.Errorf("isoWeekStart(%d, %d) = %s, in week %d-W%02d", y, w, start.Format(dateFmt), gotY, gotW)
+			}
+		}
+	}
+}
+
+func TestFromTo(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"", "", ".."},
+		{"2024-01", "", "2024-01-01.."},
+		{"", "2024-01", "..2024-01-31"},
+		{"2024-01", "2024-02", "2024-01-01..2024-02-29"},
+		{"2024-01..2024-02", "", "2024-01-01..2024-02-29"},
+		{"2024-01..2024-02", "2024-03", "2024-01-01..2024-03-31"},
+		{"-7d", "today", "2024-03-08..2024-03-15"},
+	}
+	for _, tt := range tests {
+		got, err := FromTo(tt.from, tt.to, now)
+		if err != nil {
+			t.Errorf("FromTo(%q, %q) error: %v", tt.from, tt.to, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("FromTo(%q, %q) = %s, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+	if _, err := FromTo("2024-03", "2024-02", now); err == nil {
+		t.Errorf("FromTo with --from after --to, want an error")
+	}
+}
+
+func TestBound(t *testing.T) {
+	earliest, latest := date(2024, 1, 10), date(2024, 3, 15)
+	tests := []struct {
+		r    Range
+		want string
+	}{
+		{Range{}, "2024-01-10..2024-03-15"},
+		{Range{Start: date(2024, 2, 1)}, "2024-02-01..2024-03-15"},
+		{Range{End: date(2024, 2, 1)}, "2024-01-10..2024-02-01"},
+		{Range{date(2023, 1, 1), date(2025, 1, 1)}, "2024-01-10..2024-03-15"},
+	}
+	for _, tt := range tests {
+		got, err := tt.r.Bound(earliest, latest)
+		if err != nil {
+			t.Errorf("%s.Bound error: %v", tt.r, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s.Bound = %s, want %s", tt.r, got, tt.want)
+		}
+	}
+	if _, err := (Range{Start: date(2024, 4, 1)}).Bound(earliest, latest); err == nil {
+		t.Errorf("Bound of a range after latest, want an error")
+	}
+	if _, err := (Range{}).Bound(time.Time{}, latest); err == nil {
+		t.Errorf("Bound without a start, want an error")
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 3, 11, 0, 0, 0, 0, time.Local), date(2024, 3, 11)},
+		{time.Date(2024, 3, 17, 23, 59, 0, 0, time.Local), date(2024, 3, 11)},
+		{time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local), date(2024, 12, 30)},
+		{time.Date(2021, 1, 3, 12, 0, 0, 0, time.Local), date(2020, 12, 28)},
+	}
+	for _, tt := range tests {
+		if got := WeekStart(tt.t); !got.Equal(tt.want) {
+			t.Errorf("WeekStart(%s) = %s, want %s", tt.t, got.Format(dateFmt), tt.want.Format(dateFmt))
+		}
+	}
+}
@@ -6,42 +6,280 @@ import (
 	"log"
 )
 
-func GetDb() *sql.DB {
-	db, err := sql.Open("sqlite3", "test.db")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	_, err = db.Exec(
-		`CREATE TABLE IF NOT EXISTS WeightRecords (
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS WeightRecords (
 		id INTEGER PRIMARY KEY,
 		date DATE UNIQUE,
 		weight REAL);
-		`,
-	)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	_, err = db.Exec(
-		`CREATE TABLE IF NOT EXISTS HeartRateRecords (
+	`,
+	`CREATE TABLE IF NOT EXISTS HeartRateRecords (
 		id INTEGER PRIMARY KEY,
 		time TIME UNIQUE,
 		heartRate REAL);
-		`)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	_, err = db.Exec(
-		`CREATE TABLE IF NOT EXISTS StepsRecords (
+	`,
+	`CREATE TABLE IF NOT EXISTS StepsRecords (
 		id INTEGER PRIMARY KEY,
 		time TIME UNIQUE,
 		steps REAL);
-		`,
-	)
+	`,
+	`CREATE TABLE IF NOT EXISTS DailyStepsRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		steps REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS DailyGoals (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		steps INTEGER,
+		activeZoneMinutes INTEGER,
+		activeMinutes INTEGER,
+		caloriesOut INTEGER,
+		distance REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS HeartRateZones (
+		id INTEGER PRIMARY KEY,
+		date DATE,
+		name TEXT,
+		min INTEGER,
+		max INTEGER,
+		minutes INTEGER,
+		caloriesOut REAL,
+		custom BOOLEAN,
+		UNIQUE(date, name, custom));
+	`,
+	`CREATE TABLE IF NOT EXISTS RestingHeartRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		heartRate REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS SleepRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		minutesAsleep INTEGER,
+		timeInBed INTEGER,
+		efficiency INTEGER,
+		startTime TIME,
+		endTime TIME);
+	`,
+	`CREATE TABLE IF NOT EXISTS ActiveZoneMinutesRecords (
+		id INTEGER PRIMARY KEY,
+		time TIME UNIQUE,
+		fatBurn INTEGER,
+		cardio INTEGER,
+		peak INTEGER,
+		total INTEGER);
+	`,
+	`CREATE TABLE IF NOT EXISTS SpO2Records (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		avg REAL,
+		min REAL,
+		max REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS SpO2IntradayRecords (
+		id INTEGER PRIMARY KEY,
+		time TIME UNIQUE,
+		spo2 REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS HrvRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		dailyRmssd REAL,
+		deepRmssd REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS HrvIntradayRecords (
+		id INTEGER PRIMARY KEY,
+		time TIME UNIQUE,
+		rmssd REAL,
+		coverage REAL,
+		hf REAL,
+		lf REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS BreathingRateRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		breathingRate REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS SkinTempRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		nightlyRelative REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS CardioFitnessRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		vo2MaxLow REAL,
+		vo2MaxHigh REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS WaterRecords (
+		id INTEGER PRIMARY KEY,
+		date DATE UNIQUE,
+		ounces REAL);
+	`,
+	`CREATE TABLE IF NOT EXISTS ActivityRecords (
+		id INTEGER PRIMARY KEY,
+		logId INTEGER UNIQUE,
+		date DATE,
+		startTime TIME,
+		name TEXT,
+		duration INTEGER,
+		calories INTEGER,
+		steps INTEGER);
+	`,
+	`CREATE TABLE IF NOT EXISTS HabiticaRuleScores (
+		id INTEGER PRIMARY KEY,
+		rule TEXT,
+		date DATE,
+		times INTEGER,
+		UNIQUE(rule, date));
+	`,
+	`CREATE TABLE IF NOT EXISTS HabiticaTaskSnapshots (
+		id INTEGER PRIMARY KEY,
+		taskId TEXT,
+		date DATE,
+		type TEXT,
+		text TEXT,
+		value REAL,
+		isDue BOOLEAN,
+		completed BOOLEAN,
+		streak INTEGER,
+		UNIQUE(taskId, date));
+	`,
+	`CREATE TABLE IF NOT EXISTS HabiticaHabitSnapshots (
+		id INTEGER PRIMARY KEY,
+		taskId TEXT,
+		date DATE,
+		text TEXT,
+		up BOOLEAN,
+		down BOOLEAN,
+		frequency TEXT,
+		counterUp INTEGER,
+		counterDown INTEGER,
+		UNIQUE(taskId, date));
+	`,
+	`CREATE TABLE IF NOT EXISTS BackfillCheckpoints (
+		id INTEGER PRIMARY KEY,
+		type TEXT,
+		date DATE,
+		UNIQUE(type, date));
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabBudgets (
+		id TEXT PRIMARY KEY,
+		name TEXT,
+		lastModifiedOn TEXT,
+		firstMonth DATE,
+		lastMonth DATE,
+		currencyFormat TEXT);
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabServerKnowledge (
+		id INTEGER PRIMARY KEY,
+		budgetId TEXT,
+		resource TEXT,
+		serverKnowledge INTEGER,
+		UNIQUE(budgetId, resource));
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabAccounts (
+		id TEXT PRIMARY KEY,
+		budgetId TEXT,
+		name TEXT,
+		type TEXT,
+		onBudget BOOLEAN,
+		closed BOOLEAN,
+		balance INTEGER,
+		clearedBalance INTEGER,
+		unclearedBalance INTEGER,
+		transferPayeeId TEXT,
+		deleted BOOLEAN);
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabCategoryGroups (
+		id TEXT PRIMARY KEY,
+		budgetId TEXT,
+		name TEXT,
+		hidden BOOLEAN,
+		deleted BOOLEAN);
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabCategories (
+		id TEXT PRIMARY KEY,
+		budgetId TEXT,
+		categoryGroupId TEXT,
+		name TEXT,
+		hidden BOOLEAN,
+		budgeted INTEGER,
+		activity INTEGER,
+		balance INTEGER,
+		goalType TEXT,
+		goalTarget INTEGER,
+		deleted BOOLEAN);
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabMonths (
+		id INTEGER PRIMARY KEY,
+		budgetId TEXT,
+		month DATE,
+		income INTEGER,
+		budgeted INTEGER,
+		activity INTEGER,
+		toBeBudgeted INTEGER,
+		ageOfMoney INTEGER,
+		deleted BOOLEAN,
+		UNIQUE(budgetId, month));
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabMonthCategories (
+		id INTEGER PRIMARY KEY,
+		budgetId TEXT,
+		month DATE,
+		categoryId TEXT,
+		budgeted INTEGER,
+		activity INTEGER,
+		balance INTEGER,
+		UNIQUE(budgetId, month, categoryId));
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabPayees (
+		id TEXT PRIMARY KEY,
+		budgetId TEXT,
+		name TEXT,
+		transferAccountId TEXT,
+		deleted BOOLEAN);
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabTransactions (
+		id TEXT PRIMARY KEY,
+		budgetId TEXT,
+		date DATE,
+		amount INTEGER,
+		memo TEXT,
+		cleared TEXT,
+		approved BOOLEAN,
+		flagColor TEXT,
+		accountId TEXT,
+		payeeId TEXT,
+		categoryId TEXT,
+		transferAccountId TEXT,
+		importId TEXT,
+		deleted BOOLEAN);
+	`,
+	`CREATE TABLE IF NOT EXISTS YnabSubtransactions (
+		id TEXT PRIMARY KEY,
+		transactionId TEXT,
+		amount INTEGER,
+		memo TEXT,
+		payeeId TEXT,
+		categoryId TEXT,
+		transferAccountId TEXT,
+		deleted BOOLEAN);
+	`,
+}
+
+func GetDb() *sql.DB {
+	// wait for the lock rather than failing while a background sync writes
+	db, err := sql.Open("sqlite3", "test.db?_busy_timeout=5000")
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	for _, table := range schema {
+		_, err = db.Exec(table)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	return db
 }
@@ -0,0 +1,40 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type ActiveZoneMinutesValue struct {
+	ActiveZoneMinutes        int `json:"activeZoneMinutes"`
+	FatBurnActiveZoneMinutes int `json:"fatBurnActiveZoneMinutes"`
+	CardioActiveZoneMinutes  int `json:"cardioActiveZoneMinutes"`
+	PeakActiveZoneMinutes    int `json:"peakActiveZoneMinutes"`
+}
+
+type ActiveZoneMinute struct {
+	Minute string                 `json:"minute"`
+	Value  ActiveZoneMinutesValue `json:"value"`
+}
+
+type ActiveZoneMinutesIntraday struct {
+	DateTime string             `json:"dateTime"`
+	Minutes  []ActiveZoneMinute `json:"minutes"`
+}
+
+type ActiveZoneMinutesData struct {
+	Intraday []ActiveZoneMinutesIntraday `json:"activities-active-zone-minutes-intraday"`
+}
+
+func GetActiveZoneMinutesDay(fitbitClient *http.Client, date time.Time) (*ActiveZoneMinutesData, error) {
+	azmData := ActiveZoneMinutesData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/activities/active-zone-minutes/date/%s/1d/1min.json",
+			fitbitUrl, date.Format("2006-01-02")),
+		"active zone minutes", &azmData)
+	if err != nil {
+		return nil, err
+	}
+	return &azmData, nil
+}
@@ -44,10 +44,11 @@ type Activity struct {
 }
 
 type Goals struct {
-	ActiveMinutes int     `json:"activeMinutes"`
-	CaloriesOut   int     `json:"caloriesOut"`
-	Distance      float64 `json:"distance"`
-	Steps         int     `json:"steps"`
+	ActiveMinutes     int     `json:"activeMinutes"`
+	ActiveZoneMinutes int     `json:"activeZoneMinutes"`
+	CaloriesOut       int     `json:"caloriesOut"`
+	Distance          float64 `json:"distance"`
+	Steps             int     `json:"steps"`
 }
 
 type Distance struct {
@@ -88,7 +89,7 @@ type FitnessData struct {
 type ActivitiesHeart struct {
 	DateTime string `json:"dateTime"`
 	Value    struct {
-		CustomHeartRateZones []interface{}   `json:"customHeartRateZones"`
+		CustomHeartRateZones []HeartRateZone `json:"customHeartRateZones"`
 		HeartRateZones       []HeartRateZone `json:"heartRateZones"`
 		RestingHeartRate     int             `json:"restingHeartRate"`
 	} `json:"value"`
@@ -187,14 +188,17 @@ func NewFitbitClient() *http.Client {
 	conf := &oauth2.Config{
 		ClientID:     os.Getenv("FITBIT_CLIENT_ID"),
 		ClientSecret: os.Getenv("FITBIT_API_KEY"),
-		Scopes:       []string{"activity", "profile", "sleep", "weight", "heartrate", "settings", "nutrition"},
-		RedirectURL:  "http://localhost:8080/fitbitCallback",
+		Scopes: []string{"activity", "profile", "sleep", "weight", "heartrate", "settings", "nutrition",
+			"oxygen_saturation", "respiratory_rate", "temperature", "cardio_fitness"},
+		RedirectURL: "http://localhost:8080/fitbitCallback",
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  "https://www.fitbit.com/oauth2/authorize",
 			TokenURL: "https://api.fitbit.com/oauth2/token",
 		},
 	}
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+		Transport: NewRateLimitTransport(http.DefaultTransport),
+	})
 	token, err := LoadToken()
 
 	if err != nil || token == nil {
@@ -222,6 +226,33 @@ func NewFitbitClient() *http.Client {
 	return client
 }
 
+// getFitbit GETs url and decodes the JSON response into v.
+func getFitbit(fitbitClient *http.Client, url, name string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return doFitbit(fitbitClient, req, name, v)
+}
+
+// doFitbit sends req and decodes the JSON response into v. name is what
+// is being fetched, for errors.
+func doFitbit(fitbitClient *http.Client, req *http.Request, name string, v any) error {
+	resp, err := fitbitClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	slog.Debug("get "+name, "status", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fitbit %s: %s", name, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("fitbit %s: %w", name, err)
+	}
+	return nil
+}
+
 func GetActivitiesToday(fitbitClient *http.Client) *FitnessData {
 
 	resp, err := fitbitClient.Get(
@@ -242,74 +273,57 @@ func GetActivitiesToday(fitbitClient *http.Client) *FitnessData {
 	return &act
 }
 
-func GetHeartDay(fitbitClient *http.Client, date time.Time) *HeartRateData {
-	resp, err := fitbitClient.Get(
-		fmt.Sprintf("%s/1/user/-/activities/heart/date/%s/1d/1min.json",
-			fitbitUrl, date.Format("2006-01-02")))
-	slog.Debug("get heart", "status", resp.StatusCode)
+// GetActivitiesDay returns the activity summary of date, with the
+// activities logged on it.
+func GetActivitiesDay(fitbitClient *http.Client, date time.Time) (*FitnessData, error) {
+	act := FitnessData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/activities/date/%s.json", fitbitUrl, date.Format("2006-01-02")),
+		"activities", &act)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Fatal("received error from fitbit getting heart")
+		return nil, err
 	}
+	return &act, nil
+}
 
+func GetHeartDay(fitbitClient *http.Client, date time.Time) (*HeartRateData, error) {
 	heartrateData := HeartRateData{}
-	err = json.NewDecoder(resp.Body).Decode(&heartrateData)
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/activities/heart/date/%s/1d/1min.json",
+			fitbitUrl, date.Format("2006-01-02")),
+		"heart", &heartrateData)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-
-	return &heartrateData
+	return &heartrateData, nil
 }
 
-func GetStepsDay(fitbitClient *http.Client, date time.Time) *StepsData {
-	resp, err := fitbitClient.Get(
-		fmt.Sprintf("%s/1/user/-/activities/steps/date/%s/1d/1min.json",
-			fitbitUrl, date.Format("2006-01-02")))
-	slog.Debug("get steps", "status", resp.StatusCode)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Fatal("received error from fitbit getting steps")
-	}
-
+func GetStepsDay(fitbitClient *http.Client, date time.Time) (*StepsData, error) {
 	stepsData := StepsData{}
-	err = json.NewDecoder(resp.Body).Decode(&stepsData)
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/activities/steps/date/%s/1d/1min.json",
+			fitbitUrl, date.Format("2006-01-02")),
+		"steps", &stepsData)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return &stepsData
+	return &stepsData, nil
 }
 
-func GetWeight(fitbitClient *http.Client, date time.Time, period string) *WeightData {
+func GetWeight(fitbitClient *http.Client, date time.Time, period string) (*WeightData, error) {
 	dateStr := date.Format("2006-01-02")
 	url := fmt.Sprintf("%s/1/user/-/body/log/weight/date/%s/%s.json", fitbitUrl, dateStr, period)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	req.Header.Add("accept-language", "en_US")
 
-	resp, err := fitbitClient.Do(req)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Fatal("received error from fitbit getting weight")
-	}
 	weightData := WeightData{}
-	err = json.NewDecoder(resp.Body).Decode(&weightData)
-	if err != nil {
-		log.Fatal(err)
+	if err := doFitbit(fitbitClient, req, "weight", &weightData); err != nil {
+		return nil, err
 	}
-	return &weightData
+	return &weightData, nil
 }
 
 func AddWater(fitbitClient *http.Client, ounces int) {
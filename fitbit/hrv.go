@@ -0,0 +1,60 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type HrvDay struct {
+	DateTime string `json:"dateTime"`
+	Value    struct {
+		DailyRmssd float64 `json:"dailyRmssd"`
+		DeepRmssd  float64 `json:"deepRmssd"`
+	} `json:"value"`
+}
+
+type HrvData struct {
+	Hrv []HrvDay `json:"hrv"`
+}
+
+type HrvMinute struct {
+	Minute string `json:"minute"`
+	Value  struct {
+		Rmssd    float64 `json:"rmssd"`
+		Coverage float64 `json:"coverage"`
+		Hf       float64 `json:"hf"`
+		Lf       float64 `json:"lf"`
+	} `json:"value"`
+}
+
+type HrvIntradayDay struct {
+	DateTime string      `json:"dateTime"`
+	Minutes  []HrvMinute `json:"minutes"`
+}
+
+type HrvIntradayData struct {
+	Hrv []HrvIntradayDay `json:"hrv"`
+}
+
+func GetHrvDay(fitbitClient *http.Client, date time.Time) (*HrvData, error) {
+	hrvData := HrvData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/hrv/date/%s.json", fitbitUrl, date.Format("2006-01-02")),
+		"hrv", &hrvData)
+	if err != nil {
+		return nil, err
+	}
+	return &hrvData, nil
+}
+
+func GetHrvIntraday(fitbitClient *http.Client, date time.Time) (*HrvIntradayData, error) {
+	hrvData := HrvIntradayData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/hrv/date/%s/all.json", fitbitUrl, date.Format("2006-01-02")),
+		"hrv intraday", &hrvData)
+	if err != nil {
+		return nil, err
+	}
+	return &hrvData, nil
+}
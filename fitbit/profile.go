@@ -0,0 +1,33 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type User struct {
+	DisplayName string `json:"displayName"`
+	EncodedId   string `json:"encodedId"`
+	MemberSince string `json:"memberSince"`
+	Timezone    string `json:"timezone"`
+}
+
+type Profile struct {
+	User User `json:"user"`
+}
+
+// MemberSince returns the day the fitbit account was created,
+// which is the earliest day any data can exist for.
+func (p *Profile) MemberSince() (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", p.User.MemberSince, time.Local)
+}
+
+func GetProfile(fitbitClient *http.Client) (*Profile, error) {
+	profile := Profile{}
+	err := getFitbit(fitbitClient, fmt.Sprintf("%s/1/user/-/profile.json", fitbitUrl), "profile", &profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
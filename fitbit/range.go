@@ -0,0 +1,136 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maximum number of days fitbit returns for a single daily time series request
+const (
+	maxStepsRangeDays = 1095
+	maxHeartRangeDays = 365
+)
+
+// DateRange is an inclusive range of days.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SplitRange splits start to end into consecutive ranges of at most maxDays days.
+func SplitRange(start, end time.Time, maxDays int) []DateRange {
+	var ranges []DateRange
+	for !start.After(end) {
+		chunkEnd := start.AddDate(0, 0, maxDays-1)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		ranges = append(ranges, DateRange{Start: start, End: chunkEnd})
+		start = chunkEnd.AddDate(0, 0, 1)
+	}
+	return ranges
+}
+
+// GetStepsRange returns the daily step totals from start to end,
+// using one request per 1095 days.
+func GetStepsRange(fitbitClient *http.Client, start, end time.Time) ([]ActivitySteps, error) {
+	var steps []ActivitySteps
+	for _, r := range SplitRange(start, end, maxStepsRangeDays) {
+		stepsData := StepsData{}
+		err := getFitbit(fitbitClient,
+			fmt.Sprintf("%s/1/user/-/activities/steps/date/%s/%s.json",
+				fitbitUrl, r.Start.Format("2006-01-02"), r.End.Format("2006-01-02")),
+			"steps range", &stepsData)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, stepsData.ActivitiesSteps...)
+	}
+	return steps, nil
+}
+
+// GetHeartRange returns the daily heart summaries (resting heart rate and
+// heart rate zones) from start to end, using one request per 365 days.
+func GetHeartRange(fitbitClient *http.Client, start, end time.Time) ([]ActivitiesHeart, error) {
+	var heart []ActivitiesHeart
+	for _, r := range SplitRange(start, end, maxHeartRangeDays) {
+		heartData := HeartRateData{}
+		err := getFitbit(fitbitClient,
+			fmt.Sprintf("%s/1/user/-/activities/heart/date/%s/%s.json",
+				fitbitUrl, r.Start.Format("2006-01-02"), r.End.Format("2006-01-02")),
+			"heart range", &heartData)
+		if err != nil {
+			return nil, err
+		}
+		heart = append(heart, heartData.ActivitiesHeart...)
+	}
+	return heart, nil
+}
+
+// MaxIntradayRange is the longest span of minute data fitbit returns for
+// a single request.
+const MaxIntradayRange = 24 * time.Hour
+
+// IntradayPoint is one reading of a minute level series.
+type IntradayPoint struct {
+	Time  time.Time
+	Value int
+}
+
+// GetStepsIntradayRange returns the minute steps from start to end in one
+// request. The range may cross midnight but can be at most 24 hours long.
+func GetStepsIntradayRange(fitbitClient *http.Client, start, end time.Time) ([]IntradayPoint, error) {
+	stepsData := StepsData{}
+	if err := getIntradayRange(fitbitClient, "steps", start, end, &stepsData); err != nil {
+		return nil, err
+	}
+	return intradayPoints(start, stepsData.ActivitiesStepsIntra.Dataset)
+}
+
+// GetHeartIntradayRange returns the minute heart rate from start to end in
+// one request, like GetStepsIntradayRange.
+func GetHeartIntradayRange(fitbitClient *http.Client, start, end time.Time) ([]IntradayPoint, error) {
+	heartData := HeartRateData{}
+	if err := getIntradayRange(fitbitClient, "heart", start, end, &heartData); err != nil {
+		return nil, err
+	}
+	return intradayPoints(start, heartData.ActivitiesHeartIntraday.Dataset)
+}
+
+func getIntradayRange(fitbitClient *http.Client, resource string, start, end time.Time, v any) error {
+	name := resource + " intraday range"
+	if end.Before(start) || end.Sub(start) > MaxIntradayRange {
+		return fmt.Errorf("fitbit %s: %s to %s is not within 24 hours",
+			name, start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"))
+	}
+	return getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/activities/%s/date/%s/%s/1min/time/%s/%s.json",
+			fitbitUrl, resource, start.Format("2006-01-02"), end.Format("2006-01-02"),
+			start.Format("15:04"), end.Format("15:04")),
+		name, v)
+}
+
+// intradayPoints dates a dataset that starts on start's day. Its times
+// have no date, so a time before the one ahead of it is on the next day.
+func intradayPoints(start time.Time, dataset []IntradayData) ([]IntradayPoint, error) {
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	var points []IntradayPoint
+	prev := ""
+	for _, d := range dataset {
+		if d.Time < prev {
+			day = day.AddDate(0, 0, 1)
+		}
+		prev = d.Time
+		clock, err := time.Parse("15:04:05", d.Time)
+		if err != nil {
+			return nil, fmt.Errorf("fitbit intraday time: %w", err)
+		}
+		points = append(points, IntradayPoint{
+			Time: time.Date(day.Year(), day.Month(), day.Day(),
+				clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local),
+			Value: d.Value,
+		})
+	}
+	return points, nil
+}
@@ -0,0 +1,87 @@
+package fitbit
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// fakeClient answers every request with body, recording the urls asked for.
+func fakeClient(body string, urls *[]string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		*urls = append(*urls, r.URL.Path)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+}
+
+func TestSplitRange(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.Local) }
+	var got []string
+	for _, r := range SplitRange(day(1, 1), day(1, 10), 4) {
+		got = append(got, r.Start.Format("01-02")+".."+r.End.Format("01-02"))
+	}
+	if want := "01-01..01-04 01-05..01-08 01-09..01-10"; strings.Join(got, " ") != want {
+		t.Errorf("SplitRange = %s, want %s", strings.Join(got, " "), want)
+	}
+	if got := SplitRange(day(1, 2), day(1, 1), 4); len(got) != 0 {
+		t.Errorf("SplitRange of an empty range = %v", got)
+	}
+}
+
+func TestGetStepsIntradayRange(t *testing.T) {
+	var urls []string
+	client := fakeClient(`{"activities-steps-intraday": {"dataset": [
+		{"time": "22:58:00", "value": 12},
+		{"time": "23:59:00", "value": 3},
+		{"time": "00:00:00", "value": 0},
+		{"time": "08:30:00", "value": 40}
+	]}}`, &urls)
+	start := time.Date(2024, 3, 14, 22, 58, 0, 0, time.Local)
+	end := time.Date(2024, 3, 15, 8, 30, 0, 0, time.Local)
+	points, err := GetStepsIntradayRange(client, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/1/user/-/activities/steps/date/2024-03-14/2024-03-15/1min/time/22:58/08:30.json"; len(urls) != 1 || urls[0] != want {
+		t.Errorf("requested %v, want %s", urls, want)
+	}
+	var got []string
+	for _, p := range points {
+		got = append(got, p.Time.Format("01-02 15:04"))
+	}
+	if want := "03-14 22:58, 03-14 23:59, 03-15 00:00, 03-15 08:30"; strings.Join(got, ", ") != want {
+		t.Errorf("points at %s, want %s", strings.Join(got, ", "), want)
+	}
+	if points[3].Value != 40 {
+		t.Errorf("last point = %d, want 40", points[3].Value)
+	}
+}
+
+func TestGetHeartIntradayRangeLimit(t *testing.T) {
+	var urls []string
+	client := fakeClient(`{}`, &urls)
+	start := time.Date(2024, 3, 14, 8, 0, 0, 0, time.Local)
+	for _, end := range []time.Time{start.Add(24*time.Hour + time.Minute), start.Add(-time.Minute)} {
+		if _, err := GetHeartIntradayRange(client, start, end); err == nil {
+			t.Errorf("GetHeartIntradayRange to %s, want an error", end)
+		}
+	}
+	if len(urls) != 0 {
+		t.Errorf("requested %v for ranges over 24 hours", urls)
+	}
+	if _, err := GetHeartIntradayRange(client, start, start.Add(24*time.Hour)); err != nil {
+		t.Errorf("GetHeartIntradayRange of 24 hours: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package fitbit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/haclark30/vitus/internal/ratelimit"
+)
+
+// NewRateLimitTransport returns a transport that keeps track of fitbit's
+// rate limit headers and blocks requests once the hourly quota is used up
+// until it resets.
+func NewRateLimitTransport(transport http.RoundTripper) *ratelimit.Transport {
+	return ratelimit.New(transport, "fitbit", parseRateLimit)
+}
+
+// parseRateLimit reads fitbit's quota headers, where the reset is given
+// in seconds from now.
+func parseRateLimit(h http.Header) (int, time.Time, bool) {
+	remaining, err := strconv.Atoi(h.Get("Fitbit-Rate-Limit-Remaining"))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	reset, err := strconv.Atoi(h.Get("Fitbit-Rate-Limit-Reset"))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return remaining, time.Now().Add(time.Duration(reset) * time.Second), true
+}
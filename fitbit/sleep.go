@@ -5,16 +5,43 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 )
 
 type SleepSummary struct {
 	TotalMinutesAsleep int `json:"totalMinutesAsleep"`
+	TotalSleepRecords  int `json:"totalSleepRecords"`
+	TotalTimeInBed     int `json:"totalTimeInBed"`
+}
+
+type SleepLog struct {
+	DateOfSleep   string `json:"dateOfSleep"`
+	Duration      int    `json:"duration"`
+	Efficiency    int    `json:"efficiency"`
+	IsMainSleep   bool   `json:"isMainSleep"`
+	LogId         int64  `json:"logId"`
+	MinutesAsleep int    `json:"minutesAsleep"`
+	MinutesAwake  int    `json:"minutesAwake"`
+	StartTime     string `json:"startTime"`
+	EndTime       string `json:"endTime"`
+	TimeInBed     int    `json:"timeInBed"`
 }
 
 type SleepData struct {
+	Sleep   []SleepLog   `json:"sleep"`
 	Summary SleepSummary `json:"summary"`
 }
 
+// MainSleep returns the main sleep log of the day, if there is one.
+func (s *SleepData) MainSleep() (SleepLog, bool) {
+	for _, l := range s.Sleep {
+		if l.IsMainSleep {
+			return l, true
+		}
+	}
+	return SleepLog{}, false
+}
+
 func GetSleepToday(fitbitClient *http.Client) *SleepData {
 	url := fmt.Sprintf("%s/1.2/user/-/sleep/date/today.json", fitbitUrl)
 	resp, err := fitbitClient.Get(url)
@@ -31,3 +58,14 @@ func GetSleepToday(fitbitClient *http.Client) *SleepData {
 	}
 	return &sleepData
 }
+
+func GetSleepDay(fitbitClient *http.Client, date time.Time) (*SleepData, error) {
+	sleepData := SleepData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1.2/user/-/sleep/date/%s.json", fitbitUrl, date.Format("2006-01-02")),
+		"sleep", &sleepData)
+	if err != nil {
+		return nil, err
+	}
+	return &sleepData, nil
+}
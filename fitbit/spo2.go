@@ -0,0 +1,50 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type SpO2Data struct {
+	DateTime string `json:"dateTime"`
+	Value    struct {
+		Avg float64 `json:"avg"`
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	} `json:"value"`
+}
+
+type SpO2Minute struct {
+	Minute string  `json:"minute"`
+	Value  float64 `json:"value"`
+}
+
+type SpO2IntradayData struct {
+	DateTime string       `json:"dateTime"`
+	Minutes  []SpO2Minute `json:"minutes"`
+}
+
+// GetSpO2Day returns the SpO2 summary for the sleep ending on date.
+// The value is empty if there was no sleep with enough readings.
+func GetSpO2Day(fitbitClient *http.Client, date time.Time) (*SpO2Data, error) {
+	spo2Data := SpO2Data{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/spo2/date/%s.json", fitbitUrl, date.Format("2006-01-02")),
+		"spo2", &spo2Data)
+	if err != nil {
+		return nil, err
+	}
+	return &spo2Data, nil
+}
+
+func GetSpO2Intraday(fitbitClient *http.Client, date time.Time) (*SpO2IntradayData, error) {
+	spo2Data := SpO2IntradayData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/spo2/date/%s/all.json", fitbitUrl, date.Format("2006-01-02")),
+		"spo2 intraday", &spo2Data)
+	if err != nil {
+		return nil, err
+	}
+	return &spo2Data, nil
+}
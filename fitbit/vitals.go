@@ -0,0 +1,91 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BreathingRateDay struct {
+	DateTime string `json:"dateTime"`
+	Value    struct {
+		BreathingRate float64 `json:"breathingRate"`
+	} `json:"value"`
+}
+
+type BreathingRateData struct {
+	Br []BreathingRateDay `json:"br"`
+}
+
+type SkinTempDay struct {
+	DateTime string `json:"dateTime"`
+	Value    struct {
+		NightlyRelative float64 `json:"nightlyRelative"`
+	} `json:"value"`
+	LogType string `json:"logType"`
+}
+
+type SkinTempData struct {
+	TempSkin []SkinTempDay `json:"tempSkin"`
+}
+
+type CardioScoreDay struct {
+	DateTime string `json:"dateTime"`
+	Value    struct {
+		Vo2Max string `json:"vo2Max"`
+	} `json:"value"`
+}
+
+type CardioScoreData struct {
+	CardioScore []CardioScoreDay `json:"cardioScore"`
+}
+
+// Vo2MaxRange parses the vo2Max value, which fitbit returns either as
+// a single number or as a range like "39-43".
+func (c CardioScoreDay) Vo2MaxRange() (low, high float64, err error) {
+	lowStr, highStr, isRange := strings.Cut(c.Value.Vo2Max, "-")
+	low, err = strconv.ParseFloat(strings.TrimSpace(lowStr), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return low, low, nil
+	}
+	high, err = strconv.ParseFloat(strings.TrimSpace(highStr), 64)
+	return low, high, err
+}
+
+func GetBreathingRateDay(fitbitClient *http.Client, date time.Time) (*BreathingRateData, error) {
+	brData := BreathingRateData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/br/date/%s.json", fitbitUrl, date.Format("2006-01-02")),
+		"breathing rate", &brData)
+	if err != nil {
+		return nil, err
+	}
+	return &brData, nil
+}
+
+func GetSkinTempDay(fitbitClient *http.Client, date time.Time) (*SkinTempData, error) {
+	tempData := SkinTempData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/temp/skin/date/%s.json", fitbitUrl, date.Format("2006-01-02")),
+		"skin temperature", &tempData)
+	if err != nil {
+		return nil, err
+	}
+	return &tempData, nil
+}
+
+func GetCardioScoreDay(fitbitClient *http.Client, date time.Time) (*CardioScoreData, error) {
+	cardioData := CardioScoreData{}
+	err := getFitbit(fitbitClient,
+		fmt.Sprintf("%s/1/user/-/cardioscore/date/%s.json", fitbitUrl, date.Format("2006-01-02")),
+		"cardio score", &cardioData)
+	if err != nil {
+		return nil, err
+	}
+	return &cardioData, nil
+}
@@ -0,0 +1,53 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const maxWaterRangeDays = 1095
+
+type WaterDay struct {
+	DateTime string `json:"dateTime"`
+	Value    string `json:"value"`
+}
+
+type WaterRangeData struct {
+	Water []WaterDay `json:"foods-log-water"`
+}
+
+// GetWaterRange returns the water logged each day from start to end in
+// fluid ounces, using one request per 1095 days.
+func GetWaterRange(fitbitClient *http.Client, start, end time.Time) ([]WaterDay, error) {
+	var water []WaterDay
+	for _, r := range SplitRange(start, end, maxWaterRangeDays) {
+		url := fmt.Sprintf("%s/1/user/-/foods/log/water/date/%s/%s.json",
+			fitbitUrl, r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"))
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		// water is returned in the unit system of the locale
+		req.Header.Add("accept-language", "en_US")
+
+		waterData := WaterRangeData{}
+		if err := doFitbit(fitbitClient, req, "water range", &waterData); err != nil {
+			return nil, err
+		}
+		water = append(water, waterData.Water...)
+	}
+	return water, nil
+}
+
+// GetDailyGoals returns the user's daily activity goals.
+func GetDailyGoals(fitbitClient *http.Client) (*Goals, error) {
+	goalsData := struct {
+		Goals Goals `json:"goals"`
+	}{}
+	err := getFitbit(fitbitClient, fmt.Sprintf("%s/1/user/-/activities/goals/daily.json", fitbitUrl), "goals", &goalsData)
+	if err != nil {
+		return nil, err
+	}
+	return &goalsData.Goals, nil
+}
@@ -3,30 +3,98 @@ package habitica
 import (
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haclark30/vitus/internal/ratelimit"
+)
+
+const (
+	// requests are held back once this few are left in the window, leaving
+	// room for any other client of the same account
+	rateLimitReserve = 2
+	rateLimitWindow  = time.Minute
 )
 
+var xClientRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}-\S+$`)
+
+// XClient returns the x-client header Habitica requires from third party
+// tools, the maintainer's user id and the tool's name separated by a dash.
+func XClient(maintainerId, appName string) string {
+	return maintainerId + "-" + appName
+}
+
+// ValidXClient reports whether xClient is in the maintainer id format.
+func ValidXClient(xClient string) bool {
+	return xClientRe.MatchString(xClient)
+}
+
+// HabiticaTransport authenticates requests. NewHabiticaClient sends them
+// on through a rate limited transport keeping to Habitica's limit of 30
+// requests a minute.
 type HabiticaTransport struct {
 	Transport http.RoundTripper
 	UserId    string
 	ApiKey    string
+	XClient   string
 }
 
 func (h *HabiticaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	newReq := req.Clone(req.Context())
-	newReq.Header.Set("x-api-user", h.UserId)
-	newReq.Header.Set("x-api-key", h.ApiKey)
-	newReq.Header.Set("x-client", fmt.Sprintf("%s-vitus", h.UserId))
-	return h.Transport.RoundTrip(newReq)
+	req = req.Clone(req.Context())
+	req.Header.Set("x-api-user", h.UserId)
+	req.Header.Set("x-api-key", h.ApiKey)
+	req.Header.Set("x-client", h.XClient)
+	return h.Transport.RoundTrip(req)
+}
+
+// parseRateLimit reads the X-RateLimit headers, falling back to a full
+// window when the reset can't be parsed.
+func parseRateLimit(h http.Header) (int, time.Time, bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	reset, ok := parseReset(h.Get("X-RateLimit-Reset"))
+	if !ok {
+		reset = time.Now().Add(rateLimitWindow)
+	}
+	return remaining, reset, true
+}
 
+// parseReset parses X-RateLimit-Reset, which Habitica sends as a
+// JavaScript date string, e.g.
+// "Thu Apr 20 2023 16:09:05 GMT+0000 (Coordinated Universal Time)".
+func parseReset(v string) (time.Time, bool) {
+	v, _, _ = strings.Cut(v, " (")
+	t, err := time.Parse("Mon Jan 02 2006 15:04:05 GMT-0700", v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
 }
 
-func NewHabiticaClient(userId, apiKey string) *http.Client {
+// NewHabiticaClient returns a client authenticated as userId. xClient is
+// the x-client header, in the format XClient returns; if empty the user's
+// own id is used as the maintainer id.
+func NewHabiticaClient(userId, apiKey, xClient string) (*http.Client, error) {
+	if xClient == "" {
+		xClient = XClient(userId, "vitus")
+	}
+	if !ValidXClient(xClient) {
+		return nil, fmt.Errorf("x-client %q is not <maintainer user id>-<app name>", xClient)
+	}
+	limiter := ratelimit.New(http.DefaultTransport, "habitica", parseRateLimit)
+	limiter.Reserve = rateLimitReserve
+	limiter.RetryAfter = rateLimitWindow
 	client := &http.Client{
 		Transport: &HabiticaTransport{
-			Transport: http.DefaultTransport,
+			Transport: limiter,
 			UserId:    userId,
 			ApiKey:    apiKey,
+			XClient:   xClient,
 		},
 	}
-	return client
+	return client, nil
 }
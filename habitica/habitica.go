@@ -0,0 +1,119 @@
+package habitica
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const habiticaUrl = "https://habitica.com/api/v3"
+
+// Error is the error envelope Habitica returns with any unsuccessful response.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Name       string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("habitica %d %s: %s", e.StatusCode, e.Name, e.Message)
+}
+
+// get GETs path and decodes the "data" field of the response into v.
+func get(client *http.Client, path string, query url.Values, v any) error {
+	u := habiticaUrl + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, v)
+}
+
+// post POSTs body as JSON, or nothing if body is nil, to path and
+// decodes the "data" field of the response into v.
+func post(client *http.Client, path string, body, v any) error {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, habiticaUrl+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, v)
+}
+
+func decode(resp *http.Response, v any) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e Error
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+			return fmt.Errorf("habitica returned %s", resp.Status)
+		}
+		e.StatusCode = resp.StatusCode
+		return &e
+	}
+
+	data := struct {
+		Data any `json:"data"`
+	}{Data: v}
+	return json.NewDecoder(resp.Body).Decode(&data)
+}
+
+// GetTasks returns the user's tasks of taskType, one of Habits, Dailies,
+// Todos, Rewards or CompletedTodos, or every active task if it's empty.
+func GetTasks(client *http.Client, taskType string) ([]Task, error) {
+	query := url.Values{}
+	if taskType != "" {
+		query.Set("type", taskType)
+	}
+	var tasks []Task
+	err := get(client, "/tasks/user", query, &tasks)
+	return tasks, err
+}
+
+func GetUser(client *http.Client) (*User, error) {
+	var user User
+	err := get(client, "/user", url.Values{"userFields": {"stats,profile.name"}}, &user)
+	return &user, err
+}
+
+// ScoreTask scores a task, by id or alias, up or down. Scoring a daily
+// or todo up completes it and scoring it down marks it not done.
+func ScoreTask(client *http.Client, taskId string, up bool) (*ScoreResult, error) {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	var result ScoreResult
+	err := post(client, "/tasks/"+url.PathEscape(taskId)+"/score/"+direction, nil, &result)
+	return &result, err
+}
+
+// ScoreChecklistItem toggles whether a checklist item of a task is completed.
+func ScoreChecklistItem(client *http.Client, taskId, itemId string) (*Task, error) {
+	var task Task
+	err := post(client, "/tasks/"+url.PathEscape(taskId)+"/checklist/"+url.PathEscape(itemId)+"/score", nil, &task)
+	return &task, err
+}
+
+func CreateTodo(client *http.Client, todo NewTodo) (*Task, error) {
+	todo.Type = TypeTodo
+	var task Task
+	err := post(client, "/tasks/user", todo, &task)
+	return &task, err
+}
@@ -0,0 +1,109 @@
+package habitica
+
+// Task types as returned in Task.Type.
+const (
+	TypeHabit  = "habit"
+	TypeDaily  = "daily"
+	TypeTodo   = "todo"
+	TypeReward = "reward"
+)
+
+// Task type filters accepted by GetTasks.
+const (
+	Habits         = "habits"
+	Dailies        = "dailys"
+	Todos          = "todos"
+	Rewards        = "rewards"
+	CompletedTodos = "completedTodos"
+)
+
+// Priority is a task's difficulty, which scales the rewards for scoring it.
+var Priority = map[string]float64{
+	"trivial": 0.1,
+	"easy":    1,
+	"medium":  1.5,
+	"hard":    2,
+}
+
+type ChecklistItem struct {
+	Id        string `json:"id,omitempty"`
+	Text      string `json:"text"`
+	Completed bool   `json:"completed"`
+}
+
+// Task is a habit, daily, todo or reward. Value is the task's colour for
+// habits, dailies and todos and the gold cost of rewards.
+type Task struct {
+	Id          string          `json:"id"`
+	Alias       string          `json:"alias"`
+	Type        string          `json:"type"`
+	Text        string          `json:"text"`
+	Notes       string          `json:"notes"`
+	Tags        []string        `json:"tags"`
+	Value       float64         `json:"value"`
+	Priority    float64         `json:"priority"`
+	CreatedAt   string          `json:"createdAt"`
+	Checklist   []ChecklistItem `json:"checklist"`
+	Up          bool            `json:"up"`
+	Down        bool            `json:"down"`
+	CounterUp   int             `json:"counterUp"`
+	CounterDown int             `json:"counterDown"`
+	Frequency   string          `json:"frequency"`
+	EveryX      int             `json:"everyX"`
+	Completed   bool            `json:"completed"`
+	IsDue       bool            `json:"isDue"`
+	Streak      int             `json:"streak"`
+	Date        string          `json:"date"`
+}
+
+// ChecklistDone returns the number of completed checklist items.
+func (t Task) ChecklistDone() int {
+	done := 0
+	for _, item := range t.Checklist {
+		if item.Completed {
+			done++
+		}
+	}
+	return done
+}
+
+type Stats struct {
+	Hp          float64 `json:"hp"`
+	MaxHealth   float64 `json:"maxHealth"`
+	Mp          float64 `json:"mp"`
+	MaxMp       float64 `json:"maxMP"`
+	Exp         float64 `json:"exp"`
+	ToNextLevel float64 `json:"toNextLevel"`
+	Gp          float64 `json:"gp"`
+	Lvl         int     `json:"lvl"`
+	Class       string  `json:"class"`
+}
+
+type User struct {
+	Id      string `json:"id"`
+	Profile struct {
+		Name string `json:"name"`
+	} `json:"profile"`
+	Stats Stats `json:"stats"`
+}
+
+// ScoreResult is the user's stats after scoring a task. Delta is the
+// change in the task's value.
+type ScoreResult struct {
+	Delta float64 `json:"delta"`
+	Hp    float64 `json:"hp"`
+	Mp    float64 `json:"mp"`
+	Exp   float64 `json:"exp"`
+	Gp    float64 `json:"gp"`
+	Lvl   int     `json:"lvl"`
+}
+
+// NewTodo is a todo to create. Date is an optional due date.
+type NewTodo struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	Notes     string          `json:"notes,omitempty"`
+	Date      string          `json:"date,omitempty"`
+	Priority  float64         `json:"priority,omitempty"`
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+}
@@ -0,0 +1,134 @@
+// Package ratelimit provides an http.RoundTripper that keeps to an API's
+// rate limit, as reported in its response headers.
+package ratelimit
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const maxRetries = 3
+
+// ParseFunc reads the requests left and when the quota resets from a
+// response's headers. ok is false when the response has no rate limit
+// headers.
+type ParseFunc func(h http.Header) (remaining int, reset time.Time, ok bool)
+
+// Transport tracks the rate limit headers of responses and holds requests
+// back once Reserve or fewer are left until the quota resets. Requests
+// that still get a 429 are retried after the Retry-After delay.
+type Transport struct {
+	Transport http.RoundTripper
+	// Name is the API's name in log messages.
+	Name  string
+	Parse ParseFunc
+	// Reserve is the number of requests left for any other client of the
+	// same account.
+	Reserve int
+	// RetryAfter is the delay before retrying a 429 without a Retry-After.
+	RetryAfter time.Duration
+
+	mu        sync.Mutex
+	remaining int // -1 until the first response is seen
+	reset     time.Time
+}
+
+// New returns a transport that sends requests through transport and reads
+// the rate limit with parse.
+func New(transport http.RoundTripper, name string, parse ParseFunc) *Transport {
+	return &Transport{
+		Transport:  transport,
+		Name:       name,
+		Parse:      parse,
+		RetryAfter: time.Minute,
+		remaining:  -1,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req); err != nil {
+			return nil, err
+		}
+		newReq := req.Clone(req.Context())
+		if body != nil {
+			newReq.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := t.Transport.RoundTrip(newReq)
+		if err != nil {
+			return nil, err
+		}
+		t.update(resp)
+		if resp.StatusCode != http.StatusTooManyRequests || attempt == maxRetries {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		delay := retryAfter(resp.Header, t.RetryAfter)
+		slog.Warn(t.Name+" rate limited", "retryAfter", delay)
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// wait blocks while the rate limit is used up and reserves a request.
+// The lock is held while waiting so queued requests go out one at a time.
+func (t *Transport) wait(req *http.Request) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.remaining >= 0 && t.remaining <= t.Reserve && time.Now().Before(t.reset) {
+		delay := time.Until(t.reset)
+		slog.Warn(t.Name+" rate limit reached, waiting", "delay", delay.Round(time.Second))
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+		t.remaining = -1
+	}
+	if t.remaining > 0 {
+		t.remaining--
+	}
+	return nil
+}
+
+func (t *Transport) update(resp *http.Response) {
+	remaining, reset, ok := t.Parse(resp.Header)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.remaining = remaining
+	t.reset = reset
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(h http.Header, fallback time.Duration) time.Duration {
+	v := h.Get("Retry-After")
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return fallback
+}
@@ -0,0 +1,237 @@
+package ynab
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Milliunits is a currency amount in thousandths of the currency unit,
+// the format YNAB uses for every amount.
+type Milliunits int64
+
+// Float64 returns the amount in whole currency units.
+func (m Milliunits) Float64() float64 {
+	return float64(m) / 1000
+}
+
+// String formats the amount as a decimal with two digits.
+func (m Milliunits) String() string {
+	return fmt.Sprintf("%.2f", m.Float64())
+}
+
+// Abs returns the amount without its sign.
+func (m Milliunits) Abs() Milliunits {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// FromFloat64 converts an amount in currency units to milliunits.
+func FromFloat64(f float64) Milliunits {
+	if f < 0 {
+		return Milliunits(f*1000 - 0.5)
+	}
+	return Milliunits(f*1000 + 0.5)
+}
+
+type CurrencyFormat struct {
+	IsoCode          string `json:"iso_code"`
+	ExampleFormat    string `json:"example_format"`
+	DecimalDigits    int    `json:"decimal_digits"`
+	DecimalSeparator string `json:"decimal_separator"`
+	SymbolFirst      bool   `json:"symbol_first"`
+	GroupSeparator   string `json:"group_separator"`
+	CurrencySymbol   string `json:"currency_symbol"`
+	DisplaySymbol    bool   `json:"display_symbol"`
+}
+
+// Format formats an amount using the budget's currency format.
+func (c CurrencyFormat) Format(m Milliunits) string {
+	if c.IsoCode == "" {
+		return m.String()
+	}
+	neg := m < 0
+	if neg {
+		m = -m
+	}
+	s := fmt.Sprintf("%.*f", c.DecimalDigits, m.Float64())
+	whole, frac, _ := strings.Cut(s, ".")
+	var grouped []string
+	for len(whole) > 3 {
+		grouped = append([]string{whole[len(whole)-3:]}, grouped...)
+		whole = whole[:len(whole)-3]
+	}
+	grouped = append([]string{whole}, grouped...)
+	s = strings.Join(grouped, c.GroupSeparator)
+	if frac != "" {
+		s += c.DecimalSeparator + frac
+	}
+	if c.DisplaySymbol {
+		if c.SymbolFirst {
+			s = c.CurrencySymbol + s
+		} else {
+			s += c.CurrencySymbol
+		}
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+type Budget struct {
+	Id             string         `json:"id"`
+	Name           string         `json:"name"`
+	LastModifiedOn string         `json:"last_modified_on"`
+	FirstMonth     string         `json:"first_month"`
+	LastMonth      string         `json:"last_month"`
+	CurrencyFormat CurrencyFormat `json:"currency_format"`
+}
+
+type BudgetSettings struct {
+	CurrencyFormat CurrencyFormat `json:"currency_format"`
+}
+
+type Account struct {
+	Id               string     `json:"id"`
+	Name             string     `json:"name"`
+	Type             string     `json:"type"`
+	OnBudget         bool       `json:"on_budget"`
+	Closed           bool       `json:"closed"`
+	Note             string     `json:"note"`
+	Balance          Milliunits `json:"balance"`
+	ClearedBalance   Milliunits `json:"cleared_balance"`
+	UnclearedBalance Milliunits `json:"uncleared_balance"`
+	TransferPayeeId  string     `json:"transfer_payee_id"`
+	Deleted          bool       `json:"deleted"`
+}
+
+type CategoryGroup struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hidden     bool       `json:"hidden"`
+	Deleted    bool       `json:"deleted"`
+	Categories []Category `json:"categories"`
+}
+
+type Category struct {
+	Id                string     `json:"id"`
+	CategoryGroupId   string     `json:"category_group_id"`
+	CategoryGroupName string     `json:"category_group_name"`
+	Name              string     `json:"name"`
+	Hidden            bool       `json:"hidden"`
+	Note              string     `json:"note"`
+	Budgeted          Milliunits `json:"budgeted"`
+	Activity          Milliunits `json:"activity"`
+	Balance           Milliunits `json:"balance"`
+	GoalType          string     `json:"goal_type"`
+	GoalTarget        Milliunits `json:"goal_target"`
+	Deleted           bool       `json:"deleted"`
+}
+
+type Month struct {
+	Month        string     `json:"month"`
+	Note         string     `json:"note"`
+	Income       Milliunits `json:"income"`
+	Budgeted     Milliunits `json:"budgeted"`
+	Activity     Milliunits `json:"activity"`
+	ToBeBudgeted Milliunits `json:"to_be_budgeted"`
+	AgeOfMoney   int        `json:"age_of_money"`
+	Deleted      bool       `json:"deleted"`
+	Categories   []Category `json:"categories"`
+}
+
+type Payee struct {
+	Id                string `json:"id"`
+	Name              string `json:"name"`
+	TransferAccountId string `json:"transfer_account_id"`
+	Deleted           bool   `json:"deleted"`
+}
+
+type Subtransaction struct {
+	Id                string     `json:"id"`
+	TransactionId     string     `json:"transaction_id"`
+	Amount            Milliunits `json:"amount"`
+	Memo              string     `json:"memo"`
+	PayeeId           string     `json:"payee_id"`
+	PayeeName         string     `json:"payee_name"`
+	CategoryId        string     `json:"category_id"`
+	CategoryName      string     `json:"category_name"`
+	TransferAccountId string     `json:"transfer_account_id"`
+	Deleted           bool       `json:"deleted"`
+}
+
+type Transaction struct {
+	Id                string           `json:"id"`
+	Date              string           `json:"date"`
+	Amount            Milliunits       `json:"amount"`
+	Memo              string           `json:"memo"`
+	Cleared           string           `json:"cleared"`
+	Approved          bool             `json:"approved"`
+	FlagColor         string           `json:"flag_color"`
+	AccountId         string           `json:"account_id"`
+	AccountName       string           `json:"account_name"`
+	PayeeId           string           `json:"payee_id"`
+	PayeeName         string           `json:"payee_name"`
+	CategoryId        string           `json:"category_id"`
+	CategoryName      string           `json:"category_name"`
+	TransferAccountId string           `json:"transfer_account_id"`
+	ImportId          string           `json:"import_id"`
+	Deleted           bool             `json:"deleted"`
+	Subtransactions   []Subtransaction `json:"subtransactions"`
+}
+
+// SaveSubtransaction is a split line of a SaveTransaction.
+type SaveSubtransaction struct {
+	Amount     Milliunits `json:"amount"`
+	PayeeId    string     `json:"payee_id,omitempty"`
+	PayeeName  string     `json:"payee_name,omitempty"`
+	CategoryId string     `json:"category_id,omitempty"`
+	Memo       string     `json:"memo,omitempty"`
+}
+
+// SaveTransaction is a transaction to create, or with Id set, to update.
+// When PayeeName is set without PayeeId YNAB matches or creates the payee.
+type SaveTransaction struct {
+	Id              string               `json:"id,omitempty"`
+	AccountId       string               `json:"account_id"`
+	Date            string               `json:"date"`
+	Amount          Milliunits           `json:"amount"`
+	PayeeId         string               `json:"payee_id,omitempty"`
+	PayeeName       string               `json:"payee_name,omitempty"`
+	CategoryId      string               `json:"category_id,omitempty"`
+	Memo            string               `json:"memo,omitempty"`
+	Cleared         string               `json:"cleared,omitempty"`
+	Approved        bool                 `json:"approved"`
+	FlagColor       string               `json:"flag_color,omitempty"`
+	ImportId        string               `json:"import_id,omitempty"`
+	Subtransactions []SaveSubtransaction `json:"subtransactions,omitempty"`
+}
+
+// SaveTransaction returns t in the form used to update it.
+// Subtransactions of an existing split can't be changed so are left out.
+func (t Transaction) SaveTransaction() SaveTransaction {
+	return SaveTransaction{
+		Id:         t.Id,
+		AccountId:  t.AccountId,
+		Date:       t.Date,
+		Amount:     t.Amount,
+		PayeeId:    t.PayeeId,
+		CategoryId: t.CategoryId,
+		Memo:       t.Memo,
+		Cleared:    t.Cleared,
+		Approved:   t.Approved,
+		FlagColor:  t.FlagColor,
+	}
+}
+
+// SaveTransactionsResult is the result of creating or updating transactions.
+// Transactions with an import id that already exists in the account are not
+// created and are listed in DuplicateImportIds.
+type SaveTransactionsResult struct {
+	TransactionIds     []string      `json:"transaction_ids"`
+	Transactions       []Transaction `json:"transactions"`
+	DuplicateImportIds []string      `json:"duplicate_import_ids"`
+	ServerKnowledge    int64         `json:"server_knowledge"`
+}
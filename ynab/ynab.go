@@ -1,10 +1,21 @@
 package ynab
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
+const ynabUrl = "https://api.ynab.com/v1"
+
+// LastUsedBudget can be used in place of a budget id
+// to refer to the most recently accessed budget.
+const LastUsedBudget = "last-used"
+
 type YnabTransport struct {
 	Transport http.RoundTripper
 	Token     string
@@ -25,3 +36,225 @@ func NewYnabClient(token string) *http.Client {
 	}
 	return client
 }
+
+// Error is the error envelope YNAB returns with any non 2xx response.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	Detail     string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ynab %s %s: %s", e.Id, e.Name, e.Detail)
+}
+
+// get GETs path and decodes the "data" field of the response into v.
+func get(client *http.Client, path string, query url.Values, v any) error {
+	u := ynabUrl + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, v)
+}
+
+// send encodes body as JSON, sends it to path with method and decodes the
+// "data" field of the response into v.
+func send(client *http.Client, method, path string, body, v any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, ynabUrl+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, v)
+}
+
+func decode(resp *http.Response, v any) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var envelope struct {
+			Error Error `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+			return fmt.Errorf("ynab returned %s", resp.Status)
+		}
+		envelope.Error.StatusCode = resp.StatusCode
+		return &envelope.Error
+	}
+
+	data := struct {
+		Data any `json:"data"`
+	}{Data: v}
+	return json.NewDecoder(resp.Body).Decode(&data)
+}
+
+func GetBudgets(client *http.Client) ([]Budget, error) {
+	var data struct {
+		Budgets []Budget `json:"budgets"`
+	}
+	err := get(client, "/budgets", nil, &data)
+	return data.Budgets, err
+}
+
+func GetBudgetSettings(client *http.Client, budgetId string) (*BudgetSettings, error) {
+	var data struct {
+		Settings BudgetSettings `json:"settings"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/settings", nil, &data)
+	return &data.Settings, err
+}
+
+func GetAccounts(client *http.Client, budgetId string) ([]Account, error) {
+	accounts, _, err := GetAccountsDelta(client, budgetId, 0)
+	return accounts, err
+}
+
+// GetAccountsDelta returns the accounts changed after lastKnowledge along
+// with the new server knowledge. A lastKnowledge of 0 returns every account.
+func GetAccountsDelta(client *http.Client, budgetId string, lastKnowledge int64) ([]Account, int64, error) {
+	var data struct {
+		Accounts        []Account `json:"accounts"`
+		ServerKnowledge int64     `json:"server_knowledge"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/accounts", knowledgeQuery(lastKnowledge), &data)
+	return data.Accounts, data.ServerKnowledge, err
+}
+
+func GetCategories(client *http.Client, budgetId string) ([]CategoryGroup, error) {
+	groups, _, err := GetCategoriesDelta(client, budgetId, 0)
+	return groups, err
+}
+
+// GetCategoriesDelta returns the category groups with categories changed
+// after lastKnowledge along with the new server knowledge.
+func GetCategoriesDelta(client *http.Client, budgetId string, lastKnowledge int64) ([]CategoryGroup, int64, error) {
+	var data struct {
+		CategoryGroups  []CategoryGroup `json:"category_groups"`
+		ServerKnowledge int64           `json:"server_knowledge"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/categories", knowledgeQuery(lastKnowledge), &data)
+	return data.CategoryGroups, data.ServerKnowledge, err
+}
+
+// GetMonth returns the budget month containing month, including
+// the budgeted, activity and balance of every category.
+func GetMonth(client *http.Client, budgetId string, month time.Time) (*Month, error) {
+	var data struct {
+		Month Month `json:"month"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/months/"+month.Format("2006-01")+"-01", nil, &data)
+	return &data.Month, err
+}
+
+// GetMonthsDelta returns summaries, without categories, of the months
+// changed after lastKnowledge along with the new server knowledge.
+func GetMonthsDelta(client *http.Client, budgetId string, lastKnowledge int64) ([]Month, int64, error) {
+	var data struct {
+		Months          []Month `json:"months"`
+		ServerKnowledge int64   `json:"server_knowledge"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/months", knowledgeQuery(lastKnowledge), &data)
+	return data.Months, data.ServerKnowledge, err
+}
+
+func GetPayees(client *http.Client, budgetId string) ([]Payee, error) {
+	payees, _, err := GetPayeesDelta(client, budgetId, 0)
+	return payees, err
+}
+
+// GetPayeesDelta returns the payees changed after lastKnowledge
+// along with the new server knowledge.
+func GetPayeesDelta(client *http.Client, budgetId string, lastKnowledge int64) ([]Payee, int64, error) {
+	var data struct {
+		Payees          []Payee `json:"payees"`
+		ServerKnowledge int64   `json:"server_knowledge"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/payees", knowledgeQuery(lastKnowledge), &data)
+	return data.Payees, data.ServerKnowledge, err
+}
+
+// GetTransactions returns the transactions on or after since. A zero since
+// returns every transaction in the budget.
+func GetTransactions(client *http.Client, budgetId string, since time.Time) ([]Transaction, error) {
+	transactions, _, err := GetTransactionsDelta(client, budgetId, since, 0)
+	return transactions, err
+}
+
+// GetTransactionsDelta returns the transactions on or after since that
+// changed after lastKnowledge, including deleted ones, along with the
+// new server knowledge.
+func GetTransactionsDelta(client *http.Client, budgetId string, since time.Time, lastKnowledge int64) ([]Transaction, int64, error) {
+	query := knowledgeQuery(lastKnowledge)
+	if !since.IsZero() {
+		query.Set("since_date", since.Format("2006-01-02"))
+	}
+	var data struct {
+		Transactions    []Transaction `json:"transactions"`
+		ServerKnowledge int64         `json:"server_knowledge"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/transactions", query, &data)
+	return data.Transactions, data.ServerKnowledge, err
+}
+
+// GetUnapprovedTransactions returns every transaction not yet approved.
+func GetUnapprovedTransactions(client *http.Client, budgetId string) ([]Transaction, error) {
+	var data struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+	err := get(client, "/budgets/"+budgetId+"/transactions", url.Values{"type": {"unapproved"}}, &data)
+	return data.Transactions, err
+}
+
+// CreateTransactions creates transactions in a single request.
+func CreateTransactions(client *http.Client, budgetId string, transactions []SaveTransaction) (*SaveTransactionsResult, error) {
+	var data SaveTransactionsResult
+	body := struct {
+		Transactions []SaveTransaction `json:"transactions"`
+	}{transactions}
+	err := send(client, http.MethodPost, "/budgets/"+budgetId+"/transactions", body, &data)
+	return &data, err
+}
+
+// BulkCreateTransactions creates transactions through the bulk endpoint,
+// returning the ids created and the import ids that already existed.
+func BulkCreateTransactions(client *http.Client, budgetId string, transactions []SaveTransaction) (*SaveTransactionsResult, error) {
+	var data struct {
+		Bulk SaveTransactionsResult `json:"bulk"`
+	}
+	body := struct {
+		Transactions []SaveTransaction `json:"transactions"`
+	}{transactions}
+	err := send(client, http.MethodPost, "/budgets/"+budgetId+"/transactions/bulk", body, &data)
+	return &data.Bulk, err
+}
+
+// UpdateTransactions updates transactions, identified by Id, in a single request.
+func UpdateTransactions(client *http.Client, budgetId string, transactions []SaveTransaction) (*SaveTransactionsResult, error) {
+	var data SaveTransactionsResult
+	body := struct {
+		Transactions []SaveTransaction `json:"transactions"`
+	}{transactions}
+	err := send(client, http.MethodPatch, "/budgets/"+budgetId+"/transactions", body, &data)
+	return &data, err
+}
+
+func knowledgeQuery(lastKnowledge int64) url.Values {
+	query := url.Values{}
+	if lastKnowledge > 0 {
+		query.Set("last_knowledge_of_server", strconv.FormatInt(lastKnowledge, 10))
+	}
+	return query
+}